@@ -0,0 +1,70 @@
+// Package atdf reads and writes ATDF, the ASCII form of STDF V4.
+//
+// An ATDF file holds one record per line: the three letter record name, a
+// colon and the record's fields separated by '|'. A line starting with a
+// space continues the record of the line before it. Empty fields are
+// missing, and trailing missing fields may be left out:
+//
+//	FAR:A|4|2|U
+//	MIR:LOT1|PART1|JOB1|node|tester|08:23:12 23-JUL-1992|08:25:00 23-JUL-1992|oper|P|1
+//	PTR:1000|1|3|1.25|P||IDD_STANDBY|||A|0|5E-06
+//
+// Records are the structs of package stdf, so a file can go from binary
+// STDF to ATDF and back. ATDF has its own field order, leaves out count
+// fields, which follow from the lists they count, and spells the flag
+// bytes TEST_FLG, PARM_FLG and PART_FLG as letter codes; OPT_FLAG follows
+// from which optional fields are empty. What standard ATDF cannot tell
+// apart is written as an extension a Reader of this package takes back:
+// an OPT_FLAG other than the one the empty fields imply, such as "no low
+// limit" rather than "low limit invalid", as a field after the last one of
+// its record, and a CPU_TYPE other than 2 as a fifth field of the FAR.
+// Flag bits the letter codes cannot spell, such as an invalid RESULT that
+// still holds a value, cannot be written at all.
+package atdf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+// ErrSyntax is wrapped by the errors reported for malformed ATDF.
+var ErrSyntax = errors.New("atdf: syntax error")
+
+// Error reports a malformed line of an ATDF file.
+type Error struct {
+	// Line number, counting from 1, of the line the record starts on
+	Line int
+	// Record name and field, if known
+	Record string
+	Field  string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	s := fmt.Sprintf("atdf: line %d", e.Line)
+	if e.Record != "" {
+		s += ": " + e.Record
+		if e.Field != "" {
+			s += "." + e.Field
+		}
+	}
+	return s + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// recordCodes maps record names to their REC_TYP and REC_SUB.
+var recordCodes = map[string][2]byte{}
+
+func init() {
+	for _, typ := range []byte{0, 1, 2, 5, 10, 15, 20, 50} {
+		for sub := 0; sub < 256; sub++ {
+			if rec := stdf.NewStdfRecord([]byte{0, 0, typ, byte(sub)}); rec != nil {
+				recordCodes[reflect.TypeOf(rec).Elem().Name()] = [2]byte{typ, byte(sub)}
+			}
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package atdf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+func testRecords() []stdf.StdfRecordType {
+	return []stdf.StdfRecordType{
+		stdf.FAR{Cpu_Type: 2, Stdf_Ver: 4},
+		stdf.ATR{MOD_TIM: 711887000, CMD_LINE: stdf.CN("conv -x")},
+		stdf.MIR{SETUP_T: 711886992, START_T: 711887100, STAT_NUM: 1, MODE_COD: 'P', RTST_COD: ' ',
+			PROT_COD: ' ', BURN_TIM: 65535, CMOD_COD: ' ', LOT_ID: stdf.CN("LOT1"), PART_TYP: stdf.CN("P1"),
+			NODE_NAM: stdf.CN("node"), TSTR_TYP: stdf.CN("J971"), JOB_NAM: stdf.CN("job"),
+			OPER_NAM: stdf.CN("op"), SUPR_NAM: stdf.CN("sup")},
+		stdf.SDR{HEAD_NUM: 1, SITE_GRP: 1, SITE_CNT: 2, SITE_NUM: stdf.KXU1{1, 2}, CARD_ID: stdf.CN("C9")},
+		stdf.PMR{PMR_INDX: 1, CHAN_TYP: 0, CHAN_NAM: stdf.CN("ch1"), LOG_NAM: stdf.CN("VDD"), HEAD_NUM: 1,
+			SITE_NUM: 1},
+		stdf.PGR{GRP_INDX: 32768, GRP_NAM: stdf.CN("g"), INDX_CNT: 2, PMR_INDX: stdf.KXU2{1, 2}},
+		stdf.PLR{GRP_CNT: 1, GRP_INDX: stdf.KXU2{32768}, GRP_MODE: stdf.KXU2{0x10}, GRP_RADX: stdf.KXU1{2},
+			PGM_CHAR: stdf.KXCN{stdf.CN("a")}, RTN_CHAR: stdf.KXCN{stdf.CN("b")}, PGM_CHAL: stdf.KXCN{nil},
+			RTN_CHAL: stdf.KXCN{stdf.CN("d")}},
+		stdf.WIR{HEAD_NUM: 1, SITE_GRP: 255, START_T: 711887200, WAFER_ID: stdf.CN("W1")},
+		stdf.WCR{WAFR_SIZ: 200, DIE_HT: 1.5, DIE_WID: 2.25, WF_UNITS: 3, WF_FLAT: 'D', CENTER_X: -32768,
+			CENTER_Y: 4, POS_X: 'R', POS_Y: 'U'},
+		stdf.PIR{HEAD_NUM: 1, SITE_NUM: 1},
+		stdf.PTR{TEST_NUM: 1000, HEAD_NUM: 1, SITE_NUM: 1, TEST_FLG: 0x81, PARM_FLG: 0xc8, RESULT: 1.25e-3,
+			TEST_TXT: stdf.CN("IDD"), OPT_FLAG: 0x02, RES_SCAL: 3, LLM_SCAL: 3, HLM_SCAL: 3, LO_LIMIT: 1e-4,
+			HI_LIMIT: 1e-3, UNITS: stdf.CN("A"), C_RESFMT: stdf.CN("%7.3f"), LO_SPEC: 0, HI_SPEC: 2e-3},
+		stdf.PTR{TEST_NUM: 1001, HEAD_NUM: 1, SITE_NUM: 1, TEST_FLG: 0x42, OPT_FLAG: 0x3f,
+			UNITS: stdf.CN("V")},
+		stdf.MPR{TEST_NUM: 2000, HEAD_NUM: 1, SITE_NUM: 1, PARM_FLG: 0x20, RTN_ICNT: 2, RSLT_CNT: 2,
+			RTN_STAT: stdf.KXN1{1, 8}, RTN_RSLT: stdf.KXR4{0.5, -0.25}, OPT_FLAG: 0x0e, LO_LIMIT: -1, HI_LIMIT: 1,
+			RTN_INDX: stdf.KXU2{1, 2}, UNITS: stdf.CN("V")},
+		stdf.FTR{TEST_NUM: 3000, HEAD_NUM: 1, SITE_NUM: 1, TEST_FLG: 0x80, OPT_FLAG: 0xc0 | 0x3e, CYCL_CNT: 77,
+			RTN_ICNT: 1, RTN_INDX: stdf.KXU2{1}, RTN_STAT: stdf.KXN1{3},
+			FAIL_PIN: stdf.DN{Bits: 9, Data: []byte{0xff, 0x01}}, VECT_NAM: stdf.CN("pat1"), PATG_NUM: 255},
+		stdf.BPS{SEQ_NAME: stdf.CN("seq")},
+		stdf.DTR{TEXT_DAT: stdf.CN("hello, world")},
+		stdf.GDR{FLD_CNT: 4, GEN_DATA: stdf.VN{{Code: 0}, {Code: 2, Value: stdf.U2(300)},
+			{Code: 10, Value: stdf.CN("x y")}, {Code: 7, Value: stdf.R4(0.5)}}},
+		stdf.EPS{},
+		stdf.PRR{HEAD_NUM: 1, SITE_NUM: 1, PART_FLG: 0x0d, NUM_TEST: 3, HARD_BIN: 5, SOFT_BIN: 65535,
+			X_COORD: 3, Y_COORD: -2, TEST_T: 120, PART_ID: stdf.CN("17"), PART_FIX: stdf.BN{0xbe, 0xef}},
+		stdf.TSR{HEAD_NUM: 255, SITE_NUM: 1, TEST_TYP: 'P', TEST_NUM: 1000, EXEC_CNT: 10, FAIL_CNT: 1,
+			ALRM_CNT: 4294967295, TEST_NAM: stdf.CN("IDD"), OPT_FLAG: 0xc8 | 0x34, TEST_MIN: 1e-4, TEST_MAX: 2e-3},
+		stdf.WRR{HEAD_NUM: 1, SITE_GRP: 255, FINISH_T: 711890000, PART_CNT: 1, RTST_CNT: 4294967295,
+			ABRT_CNT: 4294967295, GOOD_CNT: 0, FUNC_CNT: 4294967295, WAFER_ID: stdf.CN("W1")},
+		stdf.HBR{HEAD_NUM: 255, SITE_NUM: 1, HBIN_NUM: 5, HBIN_CNT: 1, HBIN_PF: 'F', HBIN_NAM: stdf.CN("bad")},
+		stdf.SBR{HEAD_NUM: 255, SITE_NUM: 1, SBIN_NUM: 7, SBIN_CNT: 1, SBIN_PF: ' '},
+		stdf.PCR{HEAD_NUM: 255, SITE_NUM: 1, PART_CNT: 1, RTST_CNT: 0, ABRT_CNT: 0, GOOD_CNT: 0,
+			FUNC_CNT: 4294967295},
+		stdf.MRR{FINISH_T: 711890100, DISP_COD: ' ', USR_DESC: stdf.CN("done")},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	roundTrip(t, testRecords())
+}
+
+func TestRoundTripFlags(t *testing.T) {
+	roundTrip(t, []stdf.StdfRecordType{
+		stdf.FAR{Cpu_Type: 1, Stdf_Ver: 4},
+		stdf.MIR{SETUP_T: 1, START_T: 2, STAT_NUM: 1, MODE_COD: 'P', RTST_COD: ' ', PROT_COD: ' ',
+			CMOD_COD: ' ', LOT_ID: stdf.CN("LOT1")},
+		// no limits rather than invalid ones, and no specs
+		stdf.PTR{TEST_NUM: 1, HEAD_NUM: 1, SITE_NUM: 1, RESULT: 1.5, OPT_FLAG: 0xce, UNITS: stdf.CN("V")},
+		// a low limit marked invalid that still has a value
+		stdf.PTR{TEST_NUM: 2, HEAD_NUM: 1, SITE_NUM: 1, RESULT: 1.5, OPT_FLAG: 0x12, LO_LIMIT: 0.5,
+			HI_LIMIT: 2, UNITS: stdf.CN("V")},
+		stdf.MPR{TEST_NUM: 3, HEAD_NUM: 1, SITE_NUM: 1, RTN_ICNT: 1, RSLT_CNT: 1, RTN_STAT: stdf.KXN1{1},
+			RTN_RSLT: stdf.KXR4{0.5}, OPT_FLAG: 0xc2, START_IN: 1, INCR_IN: 0.5, RTN_INDX: stdf.KXU2{1},
+			UNITS: stdf.CN("V")},
+		// reserved bits cleared
+		stdf.FTR{TEST_NUM: 4, HEAD_NUM: 1, SITE_NUM: 1, OPT_FLAG: 0x3f},
+		stdf.TSR{HEAD_NUM: 255, SITE_NUM: 1, TEST_TYP: 'P', TEST_NUM: 1, EXEC_CNT: 1, FAIL_CNT: 0,
+			ALRM_CNT: 0, OPT_FLAG: 0x37},
+		stdf.MRR{FINISH_T: 3, DISP_COD: ' '},
+	})
+}
+
+func TestWriterRejectsLoss(t *testing.T) {
+	for _, rec := range []stdf.StdfRecordType{
+		stdf.PTR{TEST_NUM: 1, TEST_FLG: 0x02, RESULT: 1.5, OPT_FLAG: 0x02},
+		stdf.PTR{TEST_NUM: 1, TEST_FLG: 0xc0, OPT_FLAG: 0x02},
+		stdf.MPR{TEST_NUM: 1, TEST_FLG: 0x02},
+		stdf.PTR{TEST_NUM: 1, TEST_FLG: 0x80, PARM_FLG: 0x20, OPT_FLAG: 0x02},
+		stdf.PRR{HEAD_NUM: 1, SITE_NUM: 1, PART_FLG: 0x18},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		if err := w.Write(rec); err == nil {
+			t.Errorf("no error for %s", rec.ToString())
+		}
+		if w.Flush(); buf.Len() != 0 {
+			t.Errorf("%s written as %q", rec.ToString(), buf.String())
+		}
+	}
+}
+
+// roundTrip writes recs as binary STDF, converts that to ATDF and back and
+// checks that the binary files are the same.
+func roundTrip(t *testing.T, recs []stdf.StdfRecordType) {
+	t.Helper()
+	var bin bytes.Buffer
+	w := stdf.NewWriter(&bin)
+	for _, rec := range recs {
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("%T: %v", rec, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// binary to ATDF
+	var text bytes.Buffer
+	aw := NewWriter(&text)
+	r := stdf.NewReader(bytes.NewReader(bin.Bytes()))
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := aw.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := aw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// and back
+	var back bytes.Buffer
+	w = stdf.NewWriter(&back)
+	ar := NewReader(strings.NewReader(text.String()))
+	for {
+		rec, err := ar.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("%T: %v", rec, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(back.Bytes(), bin.Bytes()) {
+		br := stdf.NewReader(bytes.NewReader(back.Bytes()))
+		for _, want := range recs {
+			got, err := br.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ToString() != want.ToString() {
+				t.Errorf("got  %s\nwant %s", got.ToString(), want.ToString())
+			}
+		}
+		t.Errorf("round trip through ATDF differs:\n%s", text.String())
+	}
+}
+
+func TestReaderSyntax(t *testing.T) {
+	const file = `FAR:A|4|2|U
+MIR:LOT1|P1|job|node|J971|08:23:12 23-JUL-1992|08:25:00 23-jul-1992|op|P|1
+XYZ:skipped
+PTR:1000|1|3|1.25|F|AH|IDD|
+ ||V|0.5|2
+PRR:1|3|17|1|P|5||3|-2|I
+`
+	r := NewReader(strings.NewReader(file))
+	var recs []stdf.StdfRecordType
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) != 4 {
+		t.Fatalf("got %d records", len(recs))
+	}
+	mir := recs[1].(*stdf.MIR)
+	if string(mir.LOT_ID) != "LOT1" || mir.SETUP_T.String() != "1992-07-23 08:23:12" || mir.STAT_NUM != 1 ||
+		mir.MODE_COD != 'P' || mir.BURN_TIM != 65535 || mir.RTST_COD != ' ' {
+		t.Errorf("unexpected MIR %s", mir)
+	}
+	ptr := recs[2].(*stdf.PTR)
+	if ptr.RESULT != 1.25 || !ptr.TEST_FLG.Failed() || !ptr.TEST_FLG.Alarm() || !ptr.PARM_FLG.AboveHighLimit() ||
+		string(ptr.UNITS) != "V" || ptr.LO_LIMIT != 0.5 || ptr.HI_LIMIT != 2 || !ptr.OPT_FLAG.ResScaleInvalid() ||
+		ptr.OPT_FLAG.LowLimitInvalid() || !ptr.IsMissing("LO_SPEC") {
+		t.Errorf("unexpected PTR %s", ptr)
+	}
+	prr := recs[3].(*stdf.PRR)
+	if !prr.PART_FLG.Passed() || !prr.PART_FLG.RetestByID() || prr.SOFT_BIN != 65535 || prr.Y_COORD != -2 {
+		t.Errorf("unexpected PRR %s", prr)
+	}
+
+	for _, bad := range []string{
+		"FAR:A|4|2|U\nPTR:x\n",
+		"FAR:A|4|2|U\nPTR:1|1|1|0|Q\n",
+		"FAR:A|4|2|U\nPIR:1|1|1\n",
+		"nonsense\n",
+	} {
+		r := NewReader(strings.NewReader(bad))
+		var err error
+		for err == nil {
+			_, err = r.Next()
+		}
+		var e *Error
+		if !errors.As(err, &e) || !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: got %v, want a syntax *Error", bad, err)
+		}
+	}
+}
+
+// TestLayoutsCoverFields checks that every field of a record is an ATDF
+// column, a count of one, or a flag byte spelled by derived columns.
+func TestPackageDocExample(t *testing.T) {
+	// the example file of the package doc, as written there
+	src, err := os.ReadFile("atdf.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file string
+	for _, line := range strings.Split(string(src), "\n") {
+		if strings.HasPrefix(line, "//\t") {
+			file += line[3:] + "\n"
+		}
+	}
+	r := NewReader(strings.NewReader(file))
+	var names []string
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, reflect.TypeOf(rec).Elem().Name())
+	}
+	if got := strings.Join(names, " "); got != "FAR MIR PTR" {
+		t.Fatalf("got records %s, want FAR MIR PTR", got)
+	}
+}
+
+func TestLayoutsCoverFields(t *testing.T) {
+	derived := map[string]bool{"TEST_FLG": true, "PARM_FLG": true, "OPT_FLAG": true, "PART_FLG": true}
+	for name, l := range layouts {
+		code := recordCodes[name]
+		typ := reflect.TypeOf(stdf.NewStdfRecord([]byte{0, 0, code[0], code[1]})).Elem()
+		want := map[string]bool{}
+		counts := map[string]bool{}
+		for i := 0; i < typ.NumField(); i++ {
+			if c := tagValue(typ.Field(i).Tag, "count"); c != "" {
+				counts[c] = true
+			}
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i).Name
+			if f != "BasicRecordType" && !derived[f] && !counts[f] {
+				want[f] = true
+			}
+		}
+		for _, c := range l.cols {
+			if c.get != nil {
+				continue
+			}
+			if !want[c.name] {
+				t.Errorf("%s: column %s is not a field or listed twice", name, c.name)
+			}
+			delete(want, c.name)
+		}
+		for f := range want {
+			t.Errorf("%s: field %s has no column", name, f)
+		}
+	}
+}
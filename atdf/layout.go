@@ -0,0 +1,357 @@
+package atdf
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// A column is one ATDF field of a record. Plain columns hold the STDF field
+// of the same name; derived columns, such as the pass/fail flag of a PTR,
+// are computed from and parsed into the record by get and set.
+type column struct {
+	name string
+	get  func(rec reflect.Value) string
+	set  func(rec reflect.Value, s string) error
+}
+
+// A layout is the ATDF form of one record type.
+type layout struct {
+	cols []column
+	// opt derives OPT_FLAG from the optional fields left empty. An OPT_FLAG
+	// that says more, such as "no low limit" rather than "low limit
+	// invalid", is written as one more field after the columns.
+	opt *optFlag
+	// finish sets what else follows from the columns as a whole once they
+	// are parsed.
+	finish func(rec reflect.Value, empty map[string]bool, scaled bool)
+}
+
+// optFlag is OPT_FLAG as ATDF implies it: base plus the bit of every field
+// in bits that is empty.
+type optFlag struct {
+	base uint8
+	bits map[string]uint8
+}
+
+func (o *optFlag) derive(empty func(name string) bool) uint8 {
+	f := o.base
+	for name, bit := range o.bits {
+		if empty(name) {
+			f |= bit
+		}
+	}
+	return f
+}
+
+// parametricOpt is the optFlag of PTR (mpr false) and MPR. Empty limits
+// are marked invalid (bits 4 and 5) rather than absent, so later PTRs of a
+// test take them from the first one, see stdf.PTRDefaults.
+func parametricOpt(mpr bool) *optFlag {
+	o := &optFlag{bits: map[string]uint8{
+		"RES_SCAL": 0x01, "LO_SPEC": 0x04, "HI_SPEC": 0x08, "LO_LIMIT": 0x10, "HI_LIMIT": 0x20}}
+	// bit 1 is START_IN and INCR_IN of an MPR, reserved and set in a PTR
+	if mpr {
+		o.bits["START_IN"] = 0x02
+	} else {
+		o.base = 0x02
+	}
+	return o
+}
+
+func plain(names ...string) []column {
+	cols := make([]column, len(names))
+	for i, n := range names {
+		cols[i] = column{name: n}
+	}
+	return cols
+}
+
+func cols(parts ...[]column) []column {
+	var c []column
+	for _, p := range parts {
+		c = append(c, p...)
+	}
+	return c
+}
+
+var layouts = map[string]*layout{
+	"ATR": {cols: plain("MOD_TIM", "CMD_LINE")},
+	"MIR": {cols: plain("LOT_ID", "PART_TYP", "JOB_NAM", "NODE_NAM", "TSTR_TYP", "SETUP_T", "START_T",
+		"OPER_NAM", "MODE_COD", "STAT_NUM", "SBLOT_ID", "TEST_COD", "RTST_COD", "JOB_REV", "EXEC_TYP",
+		"EXEC_VER", "PROT_COD", "CMOD_COD", "BURN_TIM", "TST_TEMP", "USER_TXT", "AUX_FILE", "PKG_TYP",
+		"FAMLY_ID", "DATE_COD", "FACIL_ID", "FLOOR_ID", "PROC_ID", "OPER_FRQ", "SPEC_NAM", "SPEC_VER",
+		"FLOW_ID", "SETUP_ID", "DSGN_REV", "ENG_ID", "ROM_COD", "SERL_NUM", "SUPR_NAM")},
+	"MRR": {cols: plain("FINISH_T", "DISP_COD", "USR_DESC", "EXC_DESC")},
+	"PCR": {cols: plain("HEAD_NUM", "SITE_NUM", "PART_CNT", "RTST_CNT", "ABRT_CNT", "GOOD_CNT", "FUNC_CNT")},
+	"HBR": {cols: plain("HEAD_NUM", "SITE_NUM", "HBIN_NUM", "HBIN_CNT", "HBIN_PF", "HBIN_NAM")},
+	"SBR": {cols: plain("HEAD_NUM", "SITE_NUM", "SBIN_NUM", "SBIN_CNT", "SBIN_PF", "SBIN_NAM")},
+	"PMR": {cols: plain("PMR_INDX", "CHAN_TYP", "CHAN_NAM", "PHY_NAM", "LOG_NAM", "HEAD_NUM", "SITE_NUM")},
+	"PGR": {cols: plain("GRP_INDX", "GRP_NAM", "PMR_INDX")},
+	"PLR": {cols: plain("GRP_INDX", "GRP_MODE", "GRP_RADX", "PGM_CHAR", "RTN_CHAR", "PGM_CHAL", "RTN_CHAL")},
+	"RDR": {cols: plain("RTST_BIN")},
+	"SDR": {cols: plain("HEAD_NUM", "SITE_GRP", "SITE_NUM", "HAND_TYP", "HAND_ID", "CARD_TYP", "CARD_ID",
+		"LOAD_TYP", "LOAD_ID", "DIB_TYP", "DIB_ID", "CABL_TYP", "CABL_ID", "CONT_TYP", "CONT_ID",
+		"LASR_TYP", "LASR_ID", "EXTR_TYP", "EXTR_ID")},
+	"WIR": {cols: plain("HEAD_NUM", "START_T", "SITE_GRP", "WAFER_ID")},
+	"WRR": {cols: plain("HEAD_NUM", "FINISH_T", "PART_CNT", "WAFER_ID", "SITE_GRP", "RTST_CNT", "ABRT_CNT",
+		"GOOD_CNT", "FUNC_CNT", "FABWF_ID", "FRAME_ID", "MASK_ID", "USR_DESC", "EXC_DESC")},
+	"WCR": {cols: plain("WF_FLAT", "POS_X", "POS_Y", "WAFR_SIZ", "DIE_HT", "DIE_WID", "WF_UNITS",
+		"CENTER_X", "CENTER_Y")},
+	"PIR": {cols: plain("HEAD_NUM", "SITE_NUM")},
+	"PRR": {cols: cols(
+		plain("HEAD_NUM", "SITE_NUM", "PART_ID", "NUM_TEST"),
+		[]column{partPassFail},
+		plain("HARD_BIN", "SOFT_BIN", "X_COORD", "Y_COORD"),
+		[]column{partRetest, partAbort},
+		plain("TEST_T", "PART_TXT", "PART_FIX"),
+	)},
+	"TSR": {
+		cols: plain("HEAD_NUM", "SITE_NUM", "TEST_NUM", "TEST_NAM", "TEST_TYP", "EXEC_CNT", "FAIL_CNT",
+			"ALRM_CNT", "SEQ_NAME", "TEST_LBL", "TEST_TIM", "TEST_MIN", "TEST_MAX", "TST_SUMS", "TST_SQRS"),
+		// bits 3, 6 and 7 are reserved and set
+		opt: &optFlag{0xc8, map[string]uint8{
+			"TEST_MIN": 0x01, "TEST_MAX": 0x02, "TEST_TIM": 0x04, "TST_SUMS": 0x10, "TST_SQRS": 0x20}},
+	},
+	"PTR": {
+		cols: cols(
+			plain("TEST_NUM", "HEAD_NUM", "SITE_NUM", "RESULT"),
+			[]column{testPassFail, alarmFlags(true)},
+			plain("TEST_TXT", "ALARM_ID"),
+			[]column{limitCompare},
+			plain("UNITS", "LO_LIMIT", "HI_LIMIT", "C_RESFMT", "C_LLMFMT", "C_HLMFMT", "LO_SPEC", "HI_SPEC",
+				"RES_SCAL", "LLM_SCAL", "HLM_SCAL"),
+		),
+		opt:    parametricOpt(false),
+		finish: parametric(false),
+	},
+	"MPR": {
+		cols: cols(
+			plain("TEST_NUM", "HEAD_NUM", "SITE_NUM", "RTN_STAT", "RTN_RSLT"),
+			[]column{testPassFail, alarmFlags(true)},
+			plain("TEST_TXT", "ALARM_ID"),
+			[]column{limitCompare},
+			plain("UNITS", "LO_LIMIT", "HI_LIMIT", "START_IN", "INCR_IN", "UNITS_IN", "RTN_INDX", "C_RESFMT",
+				"C_LLMFMT", "C_HLMFMT", "LO_SPEC", "HI_SPEC", "RES_SCAL", "LLM_SCAL", "HLM_SCAL"),
+		),
+		opt:    parametricOpt(true),
+		finish: parametric(true),
+	},
+	"FTR": {
+		cols: cols(
+			plain("TEST_NUM", "HEAD_NUM", "SITE_NUM"),
+			[]column{testPassFail, alarmFlags(false)},
+			plain("VECT_NAM", "TIME_SET", "CYCL_CNT", "REL_VADR", "REPT_CNT", "NUM_FAIL", "XFAIL_AD",
+				"YFAIL_AD", "VECT_OFF", "RTN_INDX", "RTN_STAT", "PGM_INDX", "PGM_STAT", "FAIL_PIN", "OP_CODE",
+				"TEST_TXT", "ALARM_ID", "PROG_TXT", "RSLT_TXT", "PATG_NUM", "SPIN_MAP"),
+		),
+		// bits 6 and 7 are reserved and set
+		opt: &optFlag{0xc0, map[string]uint8{
+			"CYCL_CNT": 0x01, "REL_VADR": 0x02, "REPT_CNT": 0x04, "NUM_FAIL": 0x08, "XFAIL_AD": 0x10,
+			"VECT_OFF": 0x20}},
+	},
+	"BPS": {cols: plain("SEQ_NAME")},
+	"EPS": {},
+	"DTR": {cols: plain("TEXT_DAT")},
+}
+
+// parametric returns the finish func of PTR and MPR. An empty RESULT sets
+// TEST_FLG bit 1, and values of a scaled file are brought back to SI base
+// units.
+func parametric(mpr bool) func(reflect.Value, map[string]bool, bool) {
+	return func(rec reflect.Value, empty map[string]bool, scaled bool) {
+		if empty["RESULT"] {
+			setBits(rec, "TEST_FLG", 0x02)
+		}
+		if !scaled {
+			return
+		}
+		unscale := func(field, scal string) {
+			v := rec.FieldByName(field)
+			p := math.Pow10(int(rec.FieldByName(scal).Int()))
+			if v.Kind() == reflect.Slice {
+				for i := 0; i < v.Len(); i++ {
+					v.Index(i).SetFloat(v.Index(i).Float() / p)
+				}
+				return
+			}
+			v.SetFloat(v.Float() / p)
+		}
+		if mpr {
+			unscale("RTN_RSLT", "RES_SCAL")
+		} else {
+			unscale("RESULT", "RES_SCAL")
+		}
+		unscale("LO_LIMIT", "LLM_SCAL")
+		unscale("LO_SPEC", "LLM_SCAL")
+		unscale("HI_LIMIT", "HLM_SCAL")
+		unscale("HI_SPEC", "HLM_SCAL")
+	}
+}
+
+func bits(rec reflect.Value, field string) uint8 {
+	return uint8(rec.FieldByName(field).Uint())
+}
+
+func setBits(rec reflect.Value, field string, b uint8) {
+	v := rec.FieldByName(field)
+	v.SetUint(v.Uint() | uint64(b))
+}
+
+// testPassFail is the Pass/Fail Flag of PTR, MPR and FTR: P or F from
+// TEST_FLG bit 7, A for a pass on alternate limits (PARM_FLG bit 5), and
+// empty if TEST_FLG bit 6 says there is no pass/fail indication.
+var testPassFail = column{
+	name: "Pass/Fail Flag",
+	get: func(rec reflect.Value) string {
+		f := bits(rec, "TEST_FLG")
+		switch {
+		case f&0x40 != 0:
+			return ""
+		case f&0x80 != 0:
+			return "F"
+		case rec.FieldByName("PARM_FLG").IsValid() && bits(rec, "PARM_FLG")&0x20 != 0:
+			return "A"
+		}
+		return "P"
+	},
+	set: func(rec reflect.Value, s string) error {
+		switch s {
+		case "":
+			setBits(rec, "TEST_FLG", 0x40)
+		case "F":
+			setBits(rec, "TEST_FLG", 0x80)
+		case "A":
+			if !rec.FieldByName("PARM_FLG").IsValid() {
+				return fmt.Errorf("%w: pass/fail flag %q", ErrSyntax, s)
+			}
+			setBits(rec, "PARM_FLG", 0x20)
+		case "P":
+		default:
+			return fmt.Errorf("%w: pass/fail flag %q", ErrSyntax, s)
+		}
+		return nil
+	},
+}
+
+// letterBits spell flag bits as letters; each letter stands for one bit.
+type letterBits struct {
+	field  string
+	letter byte
+	bit    uint8
+}
+
+func letterColumn(name string, letters []letterBits) column {
+	return column{
+		name: name,
+		get: func(rec reflect.Value) string {
+			var b []byte
+			for _, l := range letters {
+				if bits(rec, l.field)&l.bit != 0 {
+					b = append(b, l.letter)
+				}
+			}
+			return string(b)
+		},
+		set: func(rec reflect.Value, s string) error {
+		next:
+			for i := 0; i < len(s); i++ {
+				for _, l := range letters {
+					if s[i] == l.letter {
+						setBits(rec, l.field, l.bit)
+						continue next
+					}
+				}
+				return fmt.Errorf("%w: unknown %s letter %q", ErrSyntax, strings.ToLower(name), s[i])
+			}
+			return nil
+		},
+	}
+}
+
+// alarmFlags is the Alarm Flags column: TEST_FLG bits 0 and 2 to 5 and,
+// for PTR and MPR, PARM_FLG bits 0 to 4.
+func alarmFlags(parm bool) column {
+	letters := []letterBits{
+		{"TEST_FLG", 'A', 0x01},
+		{"TEST_FLG", 'U', 0x04},
+		{"TEST_FLG", 'T', 0x08},
+		{"TEST_FLG", 'N', 0x10},
+		{"TEST_FLG", 'X', 0x20},
+	}
+	if parm {
+		letters = append(letters,
+			letterBits{"PARM_FLG", 'S', 0x01},
+			letterBits{"PARM_FLG", 'D', 0x02},
+			letterBits{"PARM_FLG", 'O', 0x04},
+			letterBits{"PARM_FLG", 'H', 0x08},
+			letterBits{"PARM_FLG", 'L', 0x10},
+		)
+	}
+	return letterColumn("Alarm Flags", letters)
+}
+
+// limitCompare is the Limit Compare column of PTR and MPR: L and H for a
+// result equal to the low or high limit passing, PARM_FLG bits 6 and 7.
+var limitCompare = letterColumn("Limit Compare", []letterBits{
+	{"PARM_FLG", 'L', 0x40},
+	{"PARM_FLG", 'H', 0x80},
+})
+
+// partPassFail is the Pass/Fail Code of a PRR: P or F from PART_FLG bit 3,
+// empty if bit 4 says there is no pass/fail indication.
+var partPassFail = column{
+	name: "Pass/Fail Code",
+	get: func(rec reflect.Value) string {
+		f := bits(rec, "PART_FLG")
+		switch {
+		case f&0x10 != 0:
+			return ""
+		case f&0x08 != 0:
+			return "F"
+		}
+		return "P"
+	},
+	set: func(rec reflect.Value, s string) error {
+		switch s {
+		case "":
+			setBits(rec, "PART_FLG", 0x10)
+		case "F":
+			setBits(rec, "PART_FLG", 0x08)
+		case "P":
+		default:
+			return fmt.Errorf("%w: pass/fail code %q", ErrSyntax, s)
+		}
+		return nil
+	},
+}
+
+// partRetest is the Retest Code of a PRR: I for a retest of the same
+// PART_ID, C for one of the same coordinates (PART_FLG bits 0 and 1).
+var partRetest = letterColumn("Retest Code", []letterBits{
+	{"PART_FLG", 'I', 0x01},
+	{"PART_FLG", 'C', 0x02},
+})
+
+// partAbort is the Abort Code of a PRR: Y for an abnormal end of testing
+// (PART_FLG bit 2).
+var partAbort = column{
+	name: "Abort Code",
+	get: func(rec reflect.Value) string {
+		if bits(rec, "PART_FLG")&0x04 != 0 {
+			return "Y"
+		}
+		return ""
+	},
+	set: func(rec reflect.Value, s string) error {
+		switch s {
+		case "Y":
+			setBits(rec, "PART_FLG", 0x04)
+		case "", "N":
+		default:
+			return fmt.Errorf("%w: abort code %q", ErrSyntax, s)
+		}
+		return nil
+	},
+}
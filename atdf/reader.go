@@ -0,0 +1,244 @@
+package atdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+// Reader parses the records of an ATDF file.
+//
+// Records come back as the structs of package stdf with their REC_TYP and
+// REC_SUB set, ready for a stdf.Writer. As with binary STDF, PTRs are
+// resolved against the first PTR of their test, see stdf.PTRDefaults,
+// unless that is turned off with ResolveDefaults, and unknown record names
+// are skipped.
+type Reader struct {
+	r *bufio.Reader
+	// line number of the next line and the line itself, read ahead to see
+	// whether it continues the record before it
+	line    int
+	next    string
+	hasNext bool
+	eof     bool
+	// the FAR says results and limits are written in display units
+	scaled bool
+	ptr    stdf.PTRDefaults
+	raw    bool
+}
+
+// NewReader returns a Reader that parses ATDF from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// ResolveDefaults turns filling in the default data of PTRs on, as it is
+// for a new Reader, or off, as stdf.Reader.ResolveDefaults does.
+func (r *Reader) ResolveDefaults(on bool) { r.raw = !on }
+
+// readLine reads the next line, without its line ending, into r.next.
+func (r *Reader) readLine() error {
+	s, err := r.r.ReadString('\n')
+	if err == io.EOF && s != "" {
+		err = nil
+	}
+	if err != nil {
+		if err == io.EOF {
+			r.eof = true
+			r.hasNext = false
+			return nil
+		}
+		return err
+	}
+	r.line++
+	r.next, r.hasNext = strings.TrimRight(s, "\r\n"), true
+	return nil
+}
+
+// record returns the text of the next record, continuation lines joined,
+// and the line number it starts on. It returns io.EOF after the last one.
+func (r *Reader) record() (string, int, error) {
+	for {
+		if !r.hasNext && !r.eof {
+			if err := r.readLine(); err != nil {
+				return "", 0, err
+			}
+		}
+		if !r.hasNext {
+			return "", 0, io.EOF
+		}
+		if strings.TrimSpace(r.next) != "" {
+			break
+		}
+		r.hasNext = false
+	}
+	s, line := r.next, r.line
+	r.hasNext = false
+	for {
+		if err := r.readLine(); err != nil {
+			return "", 0, err
+		}
+		if !r.hasNext || !strings.HasPrefix(r.next, " ") {
+			return s, line, nil
+		}
+		s += r.next[1:]
+		r.hasNext = false
+	}
+}
+
+// Next returns the next record of the file, or io.EOF at its end.
+// Malformed records are reported as *Error.
+func (r *Reader) Next() (stdf.StdfRecordType, error) {
+	for {
+		s, line, err := r.record()
+		if err != nil {
+			return nil, err
+		}
+		if len(s) < 4 || s[3] != ':' {
+			return nil, &Error{Line: line, Err: fmt.Errorf("%w: no record name", ErrSyntax)}
+		}
+		name, body := s[:3], s[4:]
+		code, ok := recordCodes[name]
+		if !ok {
+			continue
+		}
+		rec := stdf.NewStdfRecord([]byte{0, 0, code[0], code[1]})
+		if err := r.parse(rec, name, body); err != nil {
+			if e, ok := err.(*Error); ok {
+				e.Line, e.Record = line, name
+				return nil, e
+			}
+			return nil, &Error{Line: line, Record: name, Err: err}
+		}
+		if p, ok := rec.(*stdf.PTR); ok && !r.raw {
+			r.ptr.Resolve(p)
+		}
+		return rec, nil
+	}
+}
+
+// parse sets the fields of rec from the ATDF fields in body.
+func (r *Reader) parse(rec stdf.StdfRecordType, name, body string) error {
+	fields := strings.Split(body, "|")
+	v := reflect.ValueOf(rec).Elem()
+	switch name {
+	case "FAR":
+		return r.parseFAR(v, fields)
+	case "GDR":
+		return parseGDR(v, body)
+	}
+	l := layouts[name]
+	n := len(l.cols)
+	if l.opt != nil {
+		// and OPT_FLAG, if it is not the one the empty fields imply
+		n++
+	}
+	if len(fields) > n && !(n == 0 && body == "") {
+		return fmt.Errorf("%w: %d fields, %s has %d", ErrSyntax, len(fields), name, n)
+	}
+	empty := make(map[string]bool)
+	for i, c := range l.cols {
+		s := ""
+		if i < len(fields) {
+			s = fields[i]
+		}
+		if c.set != nil {
+			if err := c.set(v, s); err != nil {
+				return &Error{Field: c.name, Err: err}
+			}
+			continue
+		}
+		sf, _ := v.Type().FieldByName(c.name)
+		fv := v.FieldByIndex(sf.Index)
+		if s == "" {
+			empty[c.name] = true
+			setMissing(fv, sf.Tag)
+			continue
+		}
+		if err := parseValue(fv, s); err != nil {
+			return &Error{Field: c.name, Err: err}
+		}
+	}
+	setCounts(v)
+	if l.opt != nil {
+		f := v.FieldByName("OPT_FLAG")
+		f.SetUint(uint64(l.opt.derive(func(name string) bool { return empty[name] })))
+		if len(fields) > len(l.cols) && fields[len(l.cols)] != "" {
+			if err := parseValue(f, fields[len(l.cols)]); err != nil {
+				return &Error{Field: "OPT_FLAG", Err: err}
+			}
+		}
+	}
+	if l.finish != nil {
+		l.finish(v, empty, r.scaled)
+	}
+	return nil
+}
+
+// setCounts sets the count fields of the record v from the lists they
+// count, and pads shorter lists to that length. A list of empty strings is
+// written as an empty field and reads back as no items, which the binary
+// encoder accepts only for lists at the end of a record.
+func setCounts(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if name := tagValue(t.Field(i).Tag, "count"); name != "" {
+			c := v.FieldByName(name)
+			if n := uint64(v.Field(i).Len()); n > c.Uint() {
+				c.SetUint(n)
+			}
+		}
+	}
+	later := false
+	for i := t.NumField() - 1; i >= 0; i-- {
+		f := v.Field(i)
+		if name := tagValue(t.Field(i).Tag, "count"); name != "" && (f.Len() > 0 || later) {
+			if n := int(v.FieldByName(name).Uint()); f.Len() < n {
+				f.Set(reflect.AppendSlice(f, reflect.MakeSlice(f.Type(), n-f.Len(), n-f.Len())))
+			}
+		}
+		later = later || !f.IsZero()
+	}
+}
+
+// parseFAR reads the FAR, A|4|2|U: data file type, STDF version, ATDF
+// version and scaling flag, and the CPU_TYPE the Writer adds after them if
+// it is not 2. CPU_TYPE is 2 if it is not given.
+func (r *Reader) parseFAR(v reflect.Value, fields []string) error {
+	if len(fields) < 3 || fields[0] != "A" || fields[2] != "2" {
+		return fmt.Errorf("%w: FAR:%s is not an ATDF V2 header", ErrSyntax, strings.Join(fields, "|"))
+	}
+	far := v.Addr().Interface().(*stdf.FAR)
+	far.Cpu_Type = 2
+	if err := parseValue(reflect.ValueOf(&far.Stdf_Ver).Elem(), fields[1]); err != nil {
+		return &Error{Field: "STDF_VER", Err: err}
+	}
+	if len(fields) > 4 && fields[4] != "" {
+		if err := parseValue(reflect.ValueOf(&far.Cpu_Type).Elem(), fields[4]); err != nil {
+			return &Error{Field: "CPU_TYPE", Err: err}
+		}
+	}
+	r.scaled = len(fields) > 3 && fields[3] == "S"
+	return nil
+}
+
+// parseGDR reads the generic data items of a GDR, one field each.
+func parseGDR(v reflect.Value, body string) error {
+	gdr := v.Addr().Interface().(*stdf.GDR)
+	if body == "" {
+		return nil
+	}
+	for _, s := range strings.Split(body, "|") {
+		g, err := parseGenData(s)
+		if err != nil {
+			return &Error{Field: "GEN_DATA", Err: err}
+		}
+		gdr.GEN_DATA = append(gdr.GEN_DATA, g)
+	}
+	gdr.FLD_CNT = stdf.U2(len(gdr.GEN_DATA))
+	return nil
+}
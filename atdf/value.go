@@ -0,0 +1,266 @@
+package atdf
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+// timeLayout is the ATDF date and time format, e.g. 08:23:12 23-JUL-1992.
+const timeLayout = "15:04:05 2-Jan-2006"
+
+var (
+	typeCN        = reflect.TypeOf(stdf.CN{})
+	typeC1        = reflect.TypeOf(stdf.C1(0))
+	typeC12       = reflect.TypeOf(stdf.C12{})
+	typeBN        = reflect.TypeOf(stdf.BN{})
+	typeCF        = reflect.TypeOf(stdf.CF{})
+	typeB6        = reflect.TypeOf(stdf.B6{})
+	typeDN        = reflect.TypeOf(stdf.DN{})
+	typeTimestamp = reflect.TypeOf(stdf.Timestamp(0))
+)
+
+// formatValue returns the ATDF text of a field value. Lists are comma
+// separated, byte strings hex and D*n fields "bits:hex".
+func formatValue(v reflect.Value) (string, error) {
+	switch v.Type() {
+	case typeCN:
+		return text(string(v.Bytes()))
+	case typeC1:
+		if v.Uint() == 0 {
+			// NUL is no character for a text file; read back as blank
+			return "", nil
+		}
+		return text(string([]byte{byte(v.Uint())}))
+	case typeC12:
+		a := v.Interface().(stdf.C12)
+		return text(string(a[:]))
+	case typeBN, typeCF:
+		return hex.EncodeToString(v.Bytes()), nil
+	case typeB6:
+		a := v.Interface().(stdf.B6)
+		return hex.EncodeToString(a[:]), nil
+	case typeDN:
+		d := v.Interface().(stdf.DN)
+		return strconv.Itoa(int(d.Bits)) + ":" + hex.EncodeToString(d.Data), nil
+	case typeTimestamp:
+		return strings.ToUpper(v.Interface().(stdf.Timestamp).In(nil).Format(timeLayout)), nil
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		s := make([]string, v.Len())
+		for i := range s {
+			x, err := formatValue(v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			if strings.Contains(x, ",") {
+				return "", fmt.Errorf("list item %q contains ','", x)
+			}
+			s[i] = x
+		}
+		return strings.Join(s, ","), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'G', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'G', -1, 64), nil
+	}
+	return "", fmt.Errorf("cannot write %s as ATDF", v.Type())
+}
+
+// text checks that s can be written as an ATDF field.
+func text(s string) (string, error) {
+	if i := strings.IndexAny(s, "|\r\n"); i >= 0 {
+		return "", fmt.Errorf("%q cannot be written to ATDF", s[i])
+	}
+	return s, nil
+}
+
+// parseValue sets v from its ATDF text s, the inverse of formatValue.
+func parseValue(v reflect.Value, s string) error {
+	switch v.Type() {
+	case typeCN:
+		v.SetBytes([]byte(s))
+		return nil
+	case typeC1:
+		if len(s) != 1 {
+			return fmt.Errorf("%w: %q is not a single character", ErrSyntax, s)
+		}
+		v.SetUint(uint64(s[0]))
+		return nil
+	case typeC12:
+		if len(s) > 12 {
+			return fmt.Errorf("%w: %q is longer than 12 characters", ErrSyntax, s)
+		}
+		var a stdf.C12
+		copy(a[:], s)
+		v.Set(reflect.ValueOf(a))
+		return nil
+	case typeBN, typeCF:
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		v.SetBytes(b)
+		return nil
+	case typeB6:
+		b, err := hex.DecodeString(s)
+		if err != nil || len(b) != 6 {
+			return fmt.Errorf("%w: %q is not 6 hex bytes", ErrSyntax, s)
+		}
+		var a stdf.B6
+		copy(a[:], b)
+		v.Set(reflect.ValueOf(a))
+		return nil
+	case typeDN:
+		i := strings.IndexByte(s, ':')
+		if i < 0 {
+			return fmt.Errorf("%w: %q is not bits:hex", ErrSyntax, s)
+		}
+		n, err := strconv.ParseUint(s[:i], 10, 16)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		b, err := hex.DecodeString(s[i+1:])
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		v.Set(reflect.ValueOf(stdf.DN{Bits: stdf.U2(n), Data: b}))
+		return nil
+	case typeTimestamp:
+		t, err := time.Parse(timeLayout, s)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		v.SetUint(uint64(stdf.NewTimestamp(t, time.UTC)))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		var items []string
+		if s != "" {
+			items = strings.Split(s, ",")
+		}
+		a := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, x := range items {
+			if err := parseValue(a.Index(i), strings.TrimSpace(x)); err != nil {
+				return err
+			}
+		}
+		v.Set(a)
+		return nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		x, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		v.SetUint(x)
+		return nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		x, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		v.SetInt(x)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		v.SetFloat(x)
+		return nil
+	}
+	return fmt.Errorf("cannot read %s from ATDF", v.Type())
+}
+
+// tagValue returns the value of key in the `stdf:"key=value,..."` tag of
+// a record field.
+func tagValue(tag reflect.StructTag, key string) string {
+	for _, opt := range strings.Split(tag.Get("stdf"), ",") {
+		if i := strings.IndexByte(opt, '='); i >= 0 && opt[:i] == key {
+			return opt[i+1:]
+		}
+	}
+	return ""
+}
+
+// setMissing sets v, a field with the given struct tag, to its
+// Missing/Invalid Data Flag, or to zero if it has none.
+func setMissing(v reflect.Value, tag reflect.StructTag) {
+	v.Set(reflect.Zero(v.Type()))
+	switch m := tagValue(tag, "missing"); m {
+	case "", "empty":
+	case "space":
+		v.SetUint(' ')
+	default:
+		// the tags are checked by package stdf, so m parses
+		_ = parseValue(v, m)
+	}
+}
+
+// genDataCodes name the V*n data types by code, as in the STDF
+// specification; code 0 is the B*0 pad.
+var genDataCodes = []string{"B0", "U1", "U2", "U4", "I1", "I2", "I4", "R4", "R8", "", "CN", "BN", "DN", "N1"}
+
+var genDataTypes = map[string]reflect.Type{
+	"U1": reflect.TypeOf(stdf.U1(0)),
+	"U2": reflect.TypeOf(stdf.U2(0)),
+	"U4": reflect.TypeOf(stdf.U4(0)),
+	"I1": reflect.TypeOf(stdf.I1(0)),
+	"I2": reflect.TypeOf(stdf.I2(0)),
+	"I4": reflect.TypeOf(stdf.I4(0)),
+	"R4": reflect.TypeOf(stdf.R4(0)),
+	"R8": reflect.TypeOf(stdf.R8(0)),
+	"CN": typeCN,
+	"BN": typeBN,
+	"DN": typeDN,
+	"N1": reflect.TypeOf(stdf.N1(0)),
+}
+
+// formatGenData writes a GDR item as its type name, a colon and its
+// value, e.g. "U2:300" or "CN:text"; the pad is "B0".
+func formatGenData(g stdf.GenData) (string, error) {
+	if int(g.Code) >= len(genDataCodes) || genDataCodes[g.Code] == "" {
+		return "", fmt.Errorf("invalid V*n data type code %d", g.Code)
+	}
+	if g.Code == 0 {
+		return "B0", nil
+	}
+	s, err := formatValue(reflect.ValueOf(g.Value))
+	return genDataCodes[g.Code] + ":" + s, err
+}
+
+func parseGenData(s string) (stdf.GenData, error) {
+	if s == "B0" {
+		return stdf.GenData{}, nil
+	}
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return stdf.GenData{}, fmt.Errorf("%w: generic data %q has no type", ErrSyntax, s)
+	}
+	t, ok := genDataTypes[s[:i]]
+	if !ok {
+		return stdf.GenData{}, fmt.Errorf("%w: unknown generic data type %q", ErrSyntax, s[:i])
+	}
+	v := reflect.New(t).Elem()
+	if err := parseValue(v, s[i+1:]); err != nil {
+		return stdf.GenData{}, err
+	}
+	var code stdf.U1
+	for c, n := range genDataCodes {
+		if n == s[:i] {
+			code = stdf.U1(c)
+		}
+	}
+	return stdf.GenData{Code: code, Value: v.Interface()}, nil
+}
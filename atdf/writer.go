@@ -0,0 +1,126 @@
+package atdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+// Writer writes records as ATDF, one line per record.
+//
+// The FAR is written as FAR:A|4|2|U, with the STDF version of the record:
+// values are written unscaled, in SI base units. A CPU_TYPE other than 2
+// follows as a fifth field, so the file goes back to binary in the byte
+// order it came from.
+type Writer struct {
+	w *bufio.Writer
+}
+
+// NewWriter returns a Writer that writes ATDF to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Write writes rec as an ATDF line. What ATDF cannot hold, such as a '|'
+// in a C*n field or flag bits the pass/fail and alarm codes cannot spell,
+// is an error and nothing is written.
+func (w *Writer) Write(rec stdf.StdfRecordType) error {
+	v := reflect.ValueOf(rec)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	name := v.Type().Name()
+	fields, err := format(rec, name, v)
+	if err != nil {
+		return fmt.Errorf("atdf: %s: %w", name, err)
+	}
+	// trailing missing fields are left out
+	n := len(fields)
+	for n > 0 && fields[n-1] == "" {
+		n--
+	}
+	_, err = w.w.WriteString(name + ":" + strings.Join(fields[:n], "|") + "\n")
+	return err
+}
+
+// format returns the ATDF fields of rec, the record struct v named name.
+func format(rec stdf.StdfRecordType, name string, v reflect.Value) ([]string, error) {
+	switch name {
+	case "FAR":
+		far := v.Interface().(stdf.FAR)
+		fields := []string{"A", strconv.Itoa(int(far.Stdf_Ver)), "2", "U"}
+		if far.Cpu_Type != 2 {
+			fields = append(fields, strconv.Itoa(int(far.Cpu_Type)))
+		}
+		return fields, nil
+	case "GDR":
+		var fields []string
+		for _, g := range v.Interface().(stdf.GDR).GEN_DATA {
+			s, err := formatGenData(g)
+			if err != nil {
+				return nil, fmt.Errorf("GEN_DATA: %w", err)
+			}
+			fields = append(fields, s)
+		}
+		return fields, nil
+	}
+	l, ok := layouts[name]
+	if !ok {
+		return nil, fmt.Errorf("not an STDF record")
+	}
+	fields := make([]string, len(l.cols))
+	for i, c := range l.cols {
+		if c.get != nil {
+			fields[i] = c.get(v)
+			continue
+		}
+		fv := v.FieldByName(c.name)
+		if rec.IsMissing(c.name) {
+			// a value a flag marks invalid is kept, the flag says the rest
+			sf, _ := v.Type().FieldByName(c.name)
+			if tagValue(sf.Tag, "flag") == "" || fv.IsZero() || stdf.FieldStatus(rec, c.name) == stdf.FieldMissing {
+				continue
+			}
+		}
+		s, err := formatValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.name, err)
+		}
+		fields[i] = s
+	}
+
+	// read the flags back as Reader does
+	empty := make(map[string]bool)
+	back := reflect.New(v.Type()).Elem()
+	for i, c := range l.cols {
+		if c.set == nil {
+			empty[c.name] = fields[i] == ""
+		} else if err := c.set(back, fields[i]); err != nil {
+			return nil, fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	if l.finish != nil {
+		l.finish(back, empty, false)
+	}
+	for _, name := range []string{"TEST_FLG", "PARM_FLG", "PART_FLG"} {
+		if f := v.FieldByName(name); f.IsValid() && f.Uint() != back.FieldByName(name).Uint() {
+			return nil, fmt.Errorf("%s %#02x has bits ATDF cannot spell", name, f.Uint())
+		}
+	}
+	if l.opt != nil && stdf.FieldStatus(rec, "OPT_FLAG") != stdf.FieldMissing {
+		if f := bits(v, "OPT_FLAG"); f != l.opt.derive(func(name string) bool { return empty[name] }) {
+			fields = append(fields, strconv.Itoa(int(f)))
+		}
+	}
+	return fields, nil
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,707 @@
+package stdf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// A recordCodec is the decode/encode plan of one record struct type.
+// It is worked out once per type by compileCodec and cached in codecs, so
+// decoding a record no longer needs to inspect its fields' types: every
+// field is reduced to a wire kind and its offset inside the struct.
+type recordCodec struct {
+	name   string
+	fields []fieldCodec
+	// index of each field in fields, by name
+	index map[string]int
+	// offset of the embedded BasicRecordType, if the record has one
+	basic    bool
+	basicOff uintptr
+}
+
+type fieldKind uint8
+
+const (
+	k8    fieldKind = iota // U1, I1, C1, B1
+	k16                    // U2, I2
+	k32                    // U4, I4, R4
+	k64                    // R8
+	kN1                    // N1
+	kFix                   // C12, B6
+	kCN                    // CN, BN
+	kDN                    // DN
+	kCF                    // CF
+	kKXU1                  // KXU1
+	kKXU2                  // KXU2
+	kKXR4                  // KXR4
+	kKXN1                  // KXN1
+	kKXCN                  // KXCN
+	kVN                    // VN
+)
+
+// fieldCodec describes a single field of a record struct.
+type fieldCodec struct {
+	name string
+	kind fieldKind
+	off  uintptr
+	// index of the struct field
+	field int
+	// byte size of kFix fields
+	size int
+	// name, offset and byte size of the count field of array kinds
+	countName string
+	countOff  uintptr
+	countSize uintptr
+	// Missing/Invalid Data Flag of the field, see compileMissing
+	missing missingRule
+}
+
+// codecs caches *recordCodec by reflect.Type
+var codecs sync.Map
+
+func codecFor(t reflect.Type) (*recordCodec, error) {
+	if c, ok := codecs.Load(t); ok {
+		return c.(*recordCodec), nil
+	}
+	c, err := compileCodec(t)
+	if err != nil {
+		return nil, err
+	}
+	codecs.Store(t, c)
+	return c, nil
+}
+
+var (
+	typeBasic = reflect.TypeOf(BasicRecordType{})
+	typeU1    = reflect.TypeOf(U1(0))
+	typeU2    = reflect.TypeOf(U2(0))
+	typeU4    = reflect.TypeOf(U4(0))
+	typeI1    = reflect.TypeOf(I1(0))
+	typeI2    = reflect.TypeOf(I2(0))
+	typeI4    = reflect.TypeOf(I4(0))
+	typeR4    = reflect.TypeOf(R4(0))
+	typeR8    = reflect.TypeOf(R8(0))
+	typeC1    = reflect.TypeOf(C1(0))
+	typeB1    = reflect.TypeOf(B1(0))
+	typeN1    = reflect.TypeOf(N1(0))
+	typeC12   = reflect.TypeOf(C12{})
+	typeB6    = reflect.TypeOf(B6{})
+	typeCF    = reflect.TypeOf(CF{})
+	typeCN    = reflect.TypeOf(CN{})
+	typeBN    = reflect.TypeOf(BN{})
+	typeDN    = reflect.TypeOf(DN{})
+	typeKXU1  = reflect.TypeOf(KXU1{})
+	typeKXU2  = reflect.TypeOf(KXU2{})
+	typeKXR4  = reflect.TypeOf(KXR4{})
+	typeKXN1  = reflect.TypeOf(KXN1{})
+	typeKXCN  = reflect.TypeOf(KXCN{})
+	typeVN    = reflect.TypeOf(VN{})
+
+	typeTestFlag = reflect.TypeOf(TestFlag(0))
+	typeParmFlag = reflect.TypeOf(ParmFlag(0))
+	typeOptFlag  = reflect.TypeOf(OptFlag(0))
+	typePartFlag = reflect.TypeOf(PartFlag(0))
+
+	typeTimestamp = reflect.TypeOf(Timestamp(0))
+)
+
+var fieldKinds = map[reflect.Type]fieldKind{
+	typeU1:   k8,
+	typeI1:   k8,
+	typeC1:   k8,
+	typeB1:   k8,
+	typeU2:   k16,
+	typeI2:   k16,
+	typeU4:   k32,
+	typeI4:   k32,
+	typeR4:   k32,
+	typeR8:   k64,
+	typeN1:   kN1,
+	typeC12:  kFix,
+	typeB6:   kFix,
+	typeCN:   kCN,
+	typeBN:   kCN,
+	typeDN:   kDN,
+	typeCF:   kCF,
+	typeKXU1: kKXU1,
+	typeKXU2: kKXU2,
+	typeKXR4: kKXR4,
+	typeKXN1: kKXN1,
+	typeKXCN: kKXCN,
+	typeVN:   kVN,
+
+	// B*1 flag bytes with named bits
+	typeTestFlag: k8,
+	typeParmFlag: k8,
+	typeOptFlag:  k8,
+	typePartFlag: k8,
+
+	typeTimestamp: k32,
+}
+
+func compileCodec(t reflect.Type) (*recordCodec, error) {
+	c := &recordCodec{name: t.Name(), index: make(map[string]int)}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Type == typeBasic {
+			c.basic, c.basicOff = true, sf.Offset
+			continue
+		}
+		kind, ok := fieldKinds[sf.Type]
+		if !ok {
+			return nil, fmt.Errorf("stdf: %s.%s has unsupported field type %s", t.Name(), sf.Name, sf.Type)
+		}
+		f := fieldCodec{name: sf.Name, kind: kind, off: sf.Offset, field: i, size: int(sf.Type.Size())}
+		if kind >= kCF {
+			cf, err := countField(t, i)
+			if err != nil {
+				return nil, err
+			}
+			f.countName, f.countOff, f.countSize = cf.Name, cf.Offset, cf.Type.Size()
+		}
+		rule, err := compileMissing(t, i)
+		if err != nil {
+			return nil, err
+		}
+		f.missing = rule
+		c.index[sf.Name] = len(c.fields)
+		c.fields = append(c.fields, f)
+	}
+	return c, nil
+}
+
+// countField returns the field holding the item count of the array field i
+// of t. Array fields name it in their struct tag, e.g.
+//
+//	SITE_NUM KXU1 `stdf:"count=SITE_CNT"`
+//
+// The count field must be an unsigned integer placed before the array.
+func countField(t reflect.Type, i int) (reflect.StructField, error) {
+	sf := t.Field(i)
+	name := tagValue(sf.Tag, "count")
+	if name == "" {
+		return sf, fmt.Errorf("stdf: %s.%s has no count tag", t.Name(), sf.Name)
+	}
+	cf, ok := t.FieldByName(name)
+	if !ok || cf.Index[0] >= i {
+		return sf, fmt.Errorf("stdf: %s.%s: count field %s not found before it", t.Name(), sf.Name, name)
+	}
+	switch cf.Type.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+	default:
+		return sf, fmt.Errorf("stdf: %s.%s: count field %s is %s, not an unsigned integer", t.Name(), sf.Name, name, cf.Type)
+	}
+	return cf, nil
+}
+
+// tagValue returns the value of key in a `stdf:"key=value,..."` struct tag.
+func tagValue(tag reflect.StructTag, key string) string {
+	for _, opt := range strings.Split(tag.Get("stdf"), ",") {
+		if k, v, ok := cut(opt, "="); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// cut is strings.Cut, which is newer than the go version of this module.
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// count returns the value of the count field of the array field f in the
+// record at p.
+func (f *fieldCodec) count(p unsafe.Pointer) int {
+	q := unsafe.Add(p, f.countOff)
+	switch f.countSize {
+	case 1:
+		return int(*(*uint8)(q))
+	case 2:
+		return int(*(*uint16)(q))
+	}
+	return int(*(*uint32)(q))
+}
+
+// zero reports whether the field f of the record at p has its zero value.
+func (f *fieldCodec) zero(p unsafe.Pointer) bool {
+	q := unsafe.Add(p, f.off)
+	switch {
+	case f.kind == kDN:
+		return (*DN)(q).Bits == 0
+	case f.kind >= kCN:
+		return len(*(*[]struct{})(q)) == 0
+	}
+	for _, c := range unsafe.Slice((*byte)(q), f.size) {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// decode decodes s into the record struct at p.
+// Fields past the end of s are set to their Missing/Invalid Data Flag, if
+// they have a value for it; how many fields were read is kept in the
+// record's BasicRecordType for FieldStatus and encode.
+func (c *recordCodec) decode(s []byte, p unsafe.Pointer, be bool) error {
+	d := decoder{s: s, be: be}
+	n := 0
+	for ; n < len(c.fields); n++ {
+		if d.m >= len(s) {
+			break
+		}
+		f := &c.fields[n]
+		d.field(f, p)
+		if d.err != nil {
+			d.err.Field = c.name + "." + f.name
+			return d.err
+		}
+	}
+	for i := n; i < len(c.fields); i++ {
+		c.fields[i].missing.fill(p, &c.fields[i])
+	}
+	if c.basic {
+		(*BasicRecordType)(unsafe.Add(p, c.basicOff)).present = n + 1
+	}
+	return nil
+}
+
+// present returns the number of fields the decoder read into the record at
+// p, or -1 if the record was not decoded.
+func (c *recordCodec) present(p unsafe.Pointer) int {
+	if !c.basic {
+		return -1
+	}
+	return (*BasicRecordType)(unsafe.Add(p, c.basicOff)).present - 1
+}
+
+func (d *decoder) field(f *fieldCodec, p unsafe.Pointer) {
+	q := unsafe.Add(p, f.off)
+	switch f.kind {
+	case k8:
+		*(*uint8)(q) = d.u1()
+	case k16:
+		*(*uint16)(q) = d.u2()
+	case k32:
+		*(*uint32)(q) = d.u4()
+	case k64:
+		*(*uint64)(q) = d.u8()
+	case kN1:
+		*(*uint8)(q) = d.u1() & 0x0f
+	case kFix:
+		copy(unsafe.Slice((*byte)(q), f.size), d.bytes(f.size))
+	case kCN:
+		*(*[]byte)(q) = d.cn()
+	case kDN:
+		*(*DN)(q) = d.dn()
+	default:
+		d.array(f.kind, f.count(p), q)
+	}
+}
+
+// array decodes n items of the array kind k into the slice at q.
+func (d *decoder) array(k fieldKind, n int, q unsafe.Pointer) {
+	switch k {
+	case kCF:
+		if d.fits(n) {
+			*(*[]byte)(q) = d.bytes(n)
+		}
+	case kKXU1:
+		if d.fits(n) {
+			a := make(KXU1, n)
+			for j := range a {
+				a[j] = U1(d.u1())
+			}
+			*(*KXU1)(q) = a
+		}
+	case kKXU2:
+		if d.fits(2 * n) {
+			a := make(KXU2, n)
+			for j := range a {
+				a[j] = U2(d.u2())
+			}
+			*(*KXU2)(q) = a
+		}
+	case kKXR4:
+		if d.fits(4 * n) {
+			a := make(KXR4, n)
+			for j := range a {
+				a[j] = R4(d.r4())
+			}
+			*(*KXR4)(q) = a
+		}
+	case kKXN1:
+		if d.fits((n + 1) / 2) {
+			a := make(KXN1, n)
+			for j := 0; j < n; j += 2 {
+				c := d.u1()
+				a[j] = N1(c & 0x0f)
+				if j+1 < n {
+					a[j+1] = N1(c >> 4)
+				}
+			}
+			*(*KXN1)(q) = a
+		}
+	case kKXCN:
+		if d.fits(n) {
+			a := make(KXCN, n)
+			for j := range a {
+				a[j] = CN(d.cn())
+			}
+			*(*KXCN)(q) = a
+		}
+	case kVN:
+		if d.fits(n) {
+			a := make(VN, n)
+			for j := range a {
+				a[j] = d.genData()
+			}
+			*(*VN)(q) = a
+		}
+	}
+}
+
+// encode appends the record struct at p to b.
+// Trailing fields that hold their Missing/Invalid Data Flag, or that a
+// flag field marks invalid, such as the spec limits of a PTR whose OPT_FLAG
+// says there are none, are left out, as testers do, unless the record was
+// decoded with them present. Of a
+// decoded record, trailing fields it did not have are left out as well
+// while they keep the zero value the decoder left them with.
+func (c *recordCodec) encode(b []byte, p unsafe.Pointer, be bool) ([]byte, error) {
+	e := encoder{b: b, be: be}
+	n, keep := len(c.fields), c.present(p)
+	for ; n > keep && n > 0; n-- {
+		f := &c.fields[n-1]
+		if !f.missing.invalid(p, f) && !(keep >= 0 && f.zero(p)) {
+			break
+		}
+	}
+	for i := 0; i < n; i++ {
+		if err := e.field(&c.fields[i], p); err != nil {
+			return nil, fmt.Errorf("stdf: %s.%s: %w", c.name, c.fields[i].name, err)
+		}
+	}
+	return e.b, nil
+}
+
+func (e *encoder) field(f *fieldCodec, p unsafe.Pointer) error {
+	q := unsafe.Add(p, f.off)
+	if f.kind > kCF {
+		// every array kind is a slice, so its length can be read generically
+		if n, c := len(*(*[]struct{})(q)), f.count(p); n != c {
+			return fmt.Errorf("%d items, but %s is %d", n, f.countName, c)
+		}
+	}
+	switch f.kind {
+	case k8:
+		e.u1(*(*uint8)(q))
+	case k16:
+		e.u2(*(*uint16)(q))
+	case k32:
+		e.u4(*(*uint32)(q))
+	case k64:
+		e.u8(*(*uint64)(q))
+	case kN1:
+		e.u1(*(*uint8)(q) & 0x0f)
+	case kFix:
+		e.b = append(e.b, unsafe.Slice((*byte)(q), f.size)...)
+	case kCN:
+		return e.cn(*(*[]byte)(q))
+	case kDN:
+		return e.dn(*(*DN)(q))
+	case kCF:
+		e.b = append(e.b, *(*[]byte)(q)...)
+	case kKXU1:
+		for _, x := range *(*KXU1)(q) {
+			e.u1(uint8(x))
+		}
+	case kKXU2:
+		for _, x := range *(*KXU2)(q) {
+			e.u2(uint16(x))
+		}
+	case kKXR4:
+		for _, x := range *(*KXR4)(q) {
+			e.r4(float32(x))
+		}
+	case kKXN1:
+		// two nibbles per byte, first item in the low 4 bits
+		a := *(*KXN1)(q)
+		for j := 0; j < len(a); j += 2 {
+			c := uint8(a[j] & 0x0f)
+			if j+1 < len(a) {
+				c |= uint8(a[j+1]&0x0f) << 4
+			}
+			e.u1(c)
+		}
+	case kKXCN:
+		for _, x := range *(*KXCN)(q) {
+			if err := e.cn(x); err != nil {
+				return err
+			}
+		}
+	case kVN:
+		for _, x := range *(*VN)(q) {
+			if err := e.genData(x); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decoder reads STDF data items from a record payload.
+// After the first failure err is set and every read returns zero values.
+type decoder struct {
+	s []byte
+	m int
+	// big-endian data
+	be  bool
+	err *DecodeError
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = &DecodeError{Offset: int64(d.m), Err: err}
+	}
+}
+
+// fits reports whether n more bytes, announced by a length or count, are
+// left in the payload.
+func (d *decoder) fits(n int) bool {
+	if d.err != nil {
+		return false
+	}
+	if n > len(d.s)-d.m {
+		d.fail(ErrBadLength)
+		return false
+	}
+	return true
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.s)-d.m {
+		d.fail(ErrShortRecord)
+		return nil
+	}
+	b := d.s[d.m : d.m+n]
+	d.m += n
+	return b
+}
+
+func (d *decoder) u1() uint8 {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) u2() uint16 {
+	if b := d.bytes(2); b != nil {
+		if d.be {
+			return binary.BigEndian.Uint16(b)
+		}
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) u4() uint32 {
+	if b := d.bytes(4); b != nil {
+		if d.be {
+			return binary.BigEndian.Uint32(b)
+		}
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) u8() uint64 {
+	if b := d.bytes(8); b != nil {
+		if d.be {
+			return binary.BigEndian.Uint64(b)
+		}
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) r4() float32 { return math.Float32frombits(d.u4()) }
+
+func (d *decoder) r8() float64 { return math.Float64frombits(d.u8()) }
+
+// cn reads a count byte and the bytes it counts.
+func (d *decoder) cn() []byte {
+	n := int(d.u1())
+	if !d.fits(n) {
+		return nil
+	}
+	return d.bytes(n)
+}
+
+func (d *decoder) dn() DN {
+	bits := U2(d.u2())
+	n := (int(bits) + 7) / 8
+	if !d.fits(n) {
+		return DN{}
+	}
+	return DN{Bits: bits, Data: d.bytes(n)}
+}
+
+// genData reads one V*n item: a data type code and the data that follows.
+func (d *decoder) genData() GenData {
+	g := GenData{Code: U1(d.u1())}
+	switch g.Code {
+	case 1:
+		g.Value = U1(d.u1())
+	case 2:
+		g.Value = U2(d.u2())
+	case 3:
+		g.Value = U4(d.u4())
+	case 4:
+		g.Value = I1(d.u1())
+	case 5:
+		g.Value = I2(d.u2())
+	case 6:
+		g.Value = I4(d.u4())
+	case 7:
+		g.Value = R4(d.r4())
+	case 8:
+		g.Value = R8(d.r8())
+	case 10:
+		g.Value = CN(d.cn())
+	case 11:
+		g.Value = BN(d.cn())
+	case 12:
+		g.Value = d.dn()
+	case 13:
+		g.Value = N1(d.u1() & 0x0f)
+	case 0:
+	default:
+		d.m--
+		d.fail(fmt.Errorf("invalid V*n data type code %d", g.Code))
+	}
+	return g
+}
+
+// encoder appends STDF data items to b.
+type encoder struct {
+	b  []byte
+	be bool
+}
+
+func (e *encoder) u1(v uint8) { e.b = append(e.b, v) }
+
+func (e *encoder) u2(v uint16) {
+	var a [2]byte
+	if e.be {
+		binary.BigEndian.PutUint16(a[:], v)
+	} else {
+		binary.LittleEndian.PutUint16(a[:], v)
+	}
+	e.b = append(e.b, a[:]...)
+}
+
+func (e *encoder) u4(v uint32) {
+	var a [4]byte
+	if e.be {
+		binary.BigEndian.PutUint32(a[:], v)
+	} else {
+		binary.LittleEndian.PutUint32(a[:], v)
+	}
+	e.b = append(e.b, a[:]...)
+}
+
+func (e *encoder) u8(v uint64) {
+	var a [8]byte
+	if e.be {
+		binary.BigEndian.PutUint64(a[:], v)
+	} else {
+		binary.LittleEndian.PutUint64(a[:], v)
+	}
+	e.b = append(e.b, a[:]...)
+}
+
+func (e *encoder) r4(v float32) { e.u4(math.Float32bits(v)) }
+
+func (e *encoder) r8(v float64) { e.u8(math.Float64bits(v)) }
+
+// cn writes a count byte followed by p.
+func (e *encoder) cn(p []byte) error {
+	if len(p) > 255 {
+		return fmt.Errorf("length %d exceeds 255", len(p))
+	}
+	e.u1(uint8(len(p)))
+	e.b = append(e.b, p...)
+	return nil
+}
+
+func (e *encoder) dn(x DN) error {
+	if n := (int(x.Bits) + 7) / 8; len(x.Data) != n {
+		return fmt.Errorf("D*n of %d bits needs %d bytes, has %d", x.Bits, n, len(x.Data))
+	}
+	e.u2(uint16(x.Bits))
+	e.b = append(e.b, x.Data...)
+	return nil
+}
+
+// genDataCodes are the V*n data type codes of the Go types of GenData.Value.
+var genDataCodes = map[reflect.Type]U1{
+	typeU1: 1, typeU2: 2, typeU4: 3,
+	typeI1: 4, typeI2: 5, typeI4: 6,
+	typeR4: 7, typeR8: 8,
+	typeCN: 10, typeBN: 11, typeDN: 12, typeN1: 13,
+}
+
+// genData writes one V*n item. Its Value must be of the type its Code
+// names, or nil for a pad of code 0.
+func (e *encoder) genData(g GenData) error {
+	if g.Value == nil {
+		if g.Code != 0 {
+			return fmt.Errorf("V*n item of type %d has no value", g.Code)
+		}
+	} else if code, ok := genDataCodes[reflect.TypeOf(g.Value)]; !ok {
+		return fmt.Errorf("unsupported V*n value %T", g.Value)
+	} else if code != g.Code {
+		return fmt.Errorf("V*n item of type %d has a %T value, which is type %d", g.Code, g.Value, code)
+	}
+	e.u1(uint8(g.Code))
+	switch x := g.Value.(type) {
+	case U1:
+		e.u1(uint8(x))
+	case U2:
+		e.u2(uint16(x))
+	case U4:
+		e.u4(uint32(x))
+	case I1:
+		e.u1(uint8(x))
+	case I2:
+		e.u2(uint16(x))
+	case I4:
+		e.u4(uint32(x))
+	case R4:
+		e.r4(float32(x))
+	case R8:
+		e.r8(float64(x))
+	case CN:
+		return e.cn(x)
+	case BN:
+		return e.cn(x)
+	case DN:
+		return e.dn(x)
+	case N1:
+		e.u1(uint8(x) & 0x0f)
+	}
+	return nil
+}
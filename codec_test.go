@@ -0,0 +1,320 @@
+package stdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func benchPTR() []byte {
+	b, err := TransS2B(PTR{
+		TEST_NUM: 1000, HEAD_NUM: 1, SITE_NUM: 3, RESULT: 1.25, TEST_TXT: CN("IDD_STANDBY"),
+		OPT_FLAG: 0x0e, RES_SCAL: -6, LLM_SCAL: -6, HLM_SCAL: -6, LO_LIMIT: 0, HI_LIMIT: 5e-6,
+		UNITS: CN("A"), C_RESFMT: CN("%9.3f"), LO_SPEC: 0, HI_SPEC: 1e-5,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestCodecMatchesLegacy(t *testing.T) {
+	b := benchPTR()
+	var got, want PTR
+	if err := TransB2S(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := transB2SLegacy(b, &want); err != nil {
+		t.Fatal(err)
+	}
+	// the legacy decoder does not keep track of the fields it read
+	want.present = got.present
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+	b2, err := TransS2B(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, b2) {
+		t.Errorf("re-encoded %v, want %v", b2, b)
+	}
+}
+
+func TestCodecUnsupportedField(t *testing.T) {
+	var v struct{ X int }
+	if err := TransB2S([]byte{1}, &v); err == nil {
+		t.Error("expected an error for an int field")
+	}
+}
+
+func BenchmarkTransB2S(b *testing.B) {
+	s := benchPTR()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var p PTR
+		if err := TransB2S(s, &p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTransB2SLegacy(b *testing.B) {
+	s := benchPTR()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var p PTR
+		if err := transB2SLegacy(s, &p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTransS2B(b *testing.B) {
+	var p PTR
+	TransB2S(benchPTR(), &p)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := TransS2B(&p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// transB2SLegacy is the string-matched reflection decoder TransB2S used
+// before the compiled codec, kept to benchmark against.
+func transB2SLegacy(s []byte, o1 interface{}) error {
+	t := reflect.TypeOf(o1)
+	v := reflect.ValueOf(o1)
+	m := 0
+	for i := 0; i < t.Elem().NumField(); i++ {
+		if m >= len(s) {
+			break
+		}
+		field := v.Elem().Field(i)
+		fT := fmt.Sprintf("%v", field.Type())
+		switch fT {
+		case "stdf.U1":
+			ii1 := U1(s[m])
+			v.Elem().Field(i).Set(reflect.ValueOf(ii1))
+			m++
+		case "stdf.U2":
+			v.Elem().Field(i).Set(reflect.ValueOf(U2(binary.LittleEndian.Uint16(s[m : m+2]))))
+			m += 2
+		case "stdf.U4":
+			v.Elem().Field(i).Set(reflect.ValueOf(U4(binary.LittleEndian.Uint32(s[m : m+4]))))
+			m += 4
+		case "stdf.C1":
+			ii1 := C1(s[m])
+			v.Elem().Field(i).Set(reflect.ValueOf(ii1))
+			m++
+		case "stdf.CN":
+			i1 := int(s[m])
+			if m+1+i1 > len(s) {
+				v.Elem().Field(i).Set(reflect.ValueOf(CN(s[m+1:])))
+			} else {
+				v.Elem().Field(i).Set(reflect.ValueOf(CN(s[m+1 : m+1+i1])))
+			}
+			m = m + 1 + i1
+		case "stdf.KXU1":
+			i1 := v.Elem().Field(i - 1).Interface().(int)
+			var t2 []U1
+			for j := 0; j < int(i1); j++ {
+				t2 = append(t2, U1(s[m+j]))
+			}
+			v.Elem().Field(i).Set(reflect.ValueOf(t2))
+			m = m + i1
+		case "stdf.I1":
+			v.Elem().Field(i).Set(reflect.ValueOf(I1(s[m])))
+			m++
+		case "stdf.I2":
+			v.Elem().Field(i).Set(reflect.ValueOf(I2(binary.LittleEndian.Uint16(s[m : m+2]))))
+			m += 2
+		case "stdf.I4":
+			v.Elem().Field(i).Set(reflect.ValueOf(I4(binary.LittleEndian.Uint32(s[m : m+4]))))
+			m += 4
+		case "stdf.R4":
+			v.Elem().Field(i).Set(reflect.ValueOf(R4(math.Float32frombits(binary.LittleEndian.Uint32(s[m : m+4])))))
+			m += 4
+		case "stdf.R8":
+			v.Elem().Field(i).Set(reflect.ValueOf(R8(math.Float64frombits(binary.LittleEndian.Uint64(s[m : m+8])))))
+			m += 8
+		case "stdf.B1":
+			v.Elem().Field(i).Set(reflect.ValueOf(B1(s[m])))
+			m++
+		case "stdf.TestFlag", "stdf.ParmFlag", "stdf.OptFlag":
+			v.Elem().Field(i).SetUint(uint64(s[m]))
+			m++
+		case "stdf.C12":
+			var c C12
+			copy(c[:], s[m:m+12])
+			v.Elem().Field(i).Set(reflect.ValueOf(c))
+			m += 12
+		case "stdf.B6":
+			var c B6
+			copy(c[:], s[m:m+6])
+			v.Elem().Field(i).Set(reflect.ValueOf(c))
+			m += 6
+		case "stdf.CF":
+			i1 := int(v.Elem().Field(i - 1).Uint())
+			v.Elem().Field(i).Set(reflect.ValueOf(CF(s[m : m+i1])))
+			m += i1
+		case "stdf.BN":
+			i1 := int(s[m])
+			v.Elem().Field(i).Set(reflect.ValueOf(BN(s[m+1 : m+1+i1])))
+			m = m + 1 + i1
+		case "stdf.DN":
+			var d DN
+			d.Bits = U2(binary.LittleEndian.Uint16(s[m : m+2]))
+			i1 := (int(d.Bits) + 7) / 8
+			d.Data = s[m+2 : m+2+i1]
+			v.Elem().Field(i).Set(reflect.ValueOf(d))
+			m = m + 2 + i1
+		case "stdf.KXU2":
+			i1 := int(v.Elem().Field(i - 1).Uint())
+			t2 := make(KXU2, i1)
+			for j := range t2 {
+				t2[j] = U2(binary.LittleEndian.Uint16(s[m+2*j:]))
+			}
+			v.Elem().Field(i).Set(reflect.ValueOf(t2))
+			m = m + 2*i1
+		case "stdf.KXR4":
+			i1 := int(v.Elem().Field(i - 1).Uint())
+			t2 := make(KXR4, i1)
+			for j := range t2 {
+				t2[j] = R4(math.Float32frombits(binary.LittleEndian.Uint32(s[m+4*j:])))
+			}
+			v.Elem().Field(i).Set(reflect.ValueOf(t2))
+			m = m + 4*i1
+		case "stdf.KXN1":
+			i1 := int(v.Elem().Field(i - 1).Uint())
+			t2 := make(KXN1, i1)
+			for j := range t2 {
+				t2[j] = N1(s[m+j/2]>>(4*(j%2))) & 0x0f
+			}
+			v.Elem().Field(i).Set(reflect.ValueOf(t2))
+			m = m + (i1+1)/2
+		case "stdf.KXCN":
+			i1 := int(v.Elem().Field(i - 1).Uint())
+			t2 := make(KXCN, i1)
+			for j := range t2 {
+				n := int(s[m])
+				t2[j] = CN(s[m+1 : m+1+n])
+				m = m + 1 + n
+			}
+			v.Elem().Field(i).Set(reflect.ValueOf(t2))
+		case "stdf.VN":
+			i1 := int(v.Elem().Field(i - 1).Uint())
+			t2 := make(VN, 0, i1)
+			for len(t2) < i1 {
+				var g GenData
+				g, m = decodeGenDataLegacy(s, m)
+				t2 = append(t2, g)
+			}
+			v.Elem().Field(i).Set(reflect.ValueOf(t2))
+		}
+	}
+	return nil
+}
+
+// decodeGenDataLegacy decodes the V*n item starting at s[m] and returns it with the
+// offset of the next item.
+func decodeGenDataLegacy(s []byte, m int) (GenData, int) {
+	g := GenData{Code: U1(s[m])}
+	m++
+	le := binary.LittleEndian
+	switch g.Code {
+	case 0:
+	case 1:
+		g.Value = U1(s[m])
+		m++
+	case 2:
+		g.Value = U2(le.Uint16(s[m:]))
+		m += 2
+	case 3:
+		g.Value = U4(le.Uint32(s[m:]))
+		m += 4
+	case 4:
+		g.Value = I1(s[m])
+		m++
+	case 5:
+		g.Value = I2(le.Uint16(s[m:]))
+		m += 2
+	case 6:
+		g.Value = I4(le.Uint32(s[m:]))
+		m += 4
+	case 7:
+		g.Value = R4(math.Float32frombits(le.Uint32(s[m:])))
+		m += 4
+	case 8:
+		g.Value = R8(math.Float64frombits(le.Uint64(s[m:])))
+		m += 8
+	case 10:
+		n := int(s[m])
+		g.Value = CN(s[m+1 : m+1+n])
+		m = m + 1 + n
+	case 11:
+		n := int(s[m])
+		g.Value = BN(s[m+1 : m+1+n])
+		m = m + 1 + n
+	case 12:
+		bits := U2(le.Uint16(s[m:]))
+		n := (int(bits) + 7) / 8
+		g.Value = DN{Bits: bits, Data: s[m+2 : m+2+n]}
+		m = m + 2 + n
+	case 13:
+		g.Value = N1(s[m] & 0x0f)
+		m++
+	}
+	return g, m
+}
+
+func TestCodecCountFields(t *testing.T) {
+	// SDR with SITE_CNT as a U1, which used to panic
+	b := []byte{1, 2, 3, 0, 1, 2, 2, 'h', '1'}
+	var sdr SDR
+	if err := TransB2S(b, &sdr); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sdr.SITE_NUM, KXU1{0, 1, 2}) || string(sdr.HAND_TYP) != "h1" {
+		t.Errorf("unexpected SDR %+v", sdr)
+	}
+
+	recs := []StdfRecordType{
+		&MPR{TEST_NUM: 7, RTN_ICNT: 3, RSLT_CNT: 2, RTN_STAT: KXN1{1, 2, 3}, RTN_RSLT: KXR4{0.5, -1},
+			TEST_TXT: CN("m"), RTN_INDX: KXU2{4, 5, 6}, UNITS: CN("V")},
+		&FTR{RTN_ICNT: 1, PGM_ICNT: 2, RTN_INDX: KXU2{9}, RTN_STAT: KXN1{7},
+			PGM_INDX: KXU2{1, 2}, PGM_STAT: KXN1{3, 4}, PATG_NUM: 255},
+		&PLR{GRP_CNT: 2, GRP_INDX: KXU2{1, 2}, GRP_MODE: KXU2{0, 0x10}, GRP_RADX: KXU1{2, 16},
+			PGM_CHAR: KXCN{CN("a"), CN("b")}, RTN_CHAR: KXCN{CN("c"), nil},
+			PGM_CHAL: KXCN{nil, nil}, RTN_CHAL: KXCN{nil, CN("d")}},
+	}
+	for _, rec := range recs {
+		b, err := TransS2B(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := reflect.New(reflect.TypeOf(rec).Elem()).Interface()
+		if err := TransB2S(b, got); err != nil {
+			t.Fatalf("%T: %v", rec, err)
+		}
+		b2, _ := TransS2B(got)
+		if !bytes.Equal(b, b2) {
+			t.Errorf("%T round trip:\n got %v\nwant %v", rec, b2, b)
+		}
+	}
+
+	if _, err := TransS2B(SDR{SITE_CNT: 2, SITE_NUM: KXU1{1}}); err == nil {
+		t.Error("expected an error for SITE_NUM not matching SITE_CNT")
+	}
+	var untagged struct {
+		N U1
+		A KXU1
+	}
+	if _, err := TransS2B(untagged); err == nil {
+		t.Error("expected an error for an array without a count tag")
+	}
+}
@@ -0,0 +1,281 @@
+package stdf
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+)
+
+// csvPartColumns are the leading columns of every ExportCSV row.
+var csvPartColumns = []string{
+	"LOT_ID", "WAFER_ID", "HEAD_NUM", "SITE_NUM", "PART_ID",
+	"X_COORD", "Y_COORD", "HARD_BIN", "SOFT_BIN", "TEST_T",
+}
+
+// csvTest identifies a test column: a test number and its text. An MPR has
+// a column for each of its results, told apart by pin.
+type csvTest struct {
+	num U4
+	txt string
+	pin int
+}
+
+// csvColumn is a test column of ExportCSV, with the units and limits of
+// the first record of its test.
+type csvColumn struct {
+	name   string
+	scale  I1
+	units  string
+	lo, hi string
+}
+
+// csvLayout collects the test columns of a stream in the order the tests
+// first appear.
+type csvLayout struct {
+	cols  []csvColumn
+	index map[csvTest]int
+	// text of the first record of each test number, for later records that
+	// leave TEST_TXT empty
+	text map[U4]string
+	// no more columns are added once the header is written
+	fixed bool
+}
+
+func newCSVLayout() *csvLayout {
+	return &csvLayout{index: make(map[csvTest]int), text: make(map[U4]string)}
+}
+
+// key returns the column key of a test record.
+func (l *csvLayout) key(num U4, txt CN, pin int) csvTest {
+	s, ok := l.text[num]
+	if !ok {
+		l.text[num] = string(txt)
+	}
+	if len(txt) > 0 {
+		s = string(txt)
+	}
+	return csvTest{num, s, pin}
+}
+
+// column returns the index of the column of k, adding a column made by
+// add if there is none yet and the layout is not fixed.
+func (l *csvLayout) column(k csvTest, add func() csvColumn) (int, bool) {
+	i, ok := l.index[k]
+	if !ok && !l.fixed {
+		i, ok = len(l.cols), true
+		c := add()
+		c.name = strconv.FormatUint(uint64(k.num), 10)
+		if k.txt != "" {
+			c.name += " " + k.txt
+		}
+		if k.pin >= 0 {
+			c.name += "[" + strconv.Itoa(k.pin) + "]"
+		}
+		l.index[k] = i
+		l.cols = append(l.cols, c)
+	}
+	return i, ok
+}
+
+// measureColumn returns a column for results of the given scale and unit,
+// with the limits lo and hi where present.
+func measureColumn(res Measure, lo Measure, hasLo bool, hi Measure, hasHi bool) csvColumn {
+	c := csvColumn{scale: res.Scale}
+	_, c.units = res.Display()
+	if hasLo {
+		c.lo = c.format(lo.Value)
+	}
+	if hasHi {
+		c.hi = c.format(hi.Value)
+	}
+	return c
+}
+
+// format returns v, in SI base units, scaled for display in column c.
+func (c *csvColumn) format(v R4) string {
+	d, _ := Measure{v, c.scale, ""}.Display()
+	return strconv.FormatFloat(float64(float32(d)), 'g', -1, 32)
+}
+
+// ExportCSV writes the parts of the STDF stream r to w as CSV, a row per
+// PRR and a column per test.
+//
+// Each row starts with the part's lot, wafer, head, site, PART_ID,
+// coordinates, bins and test time, followed by its test results. Tests get
+// a column per TEST_NUM and TEST_TXT, named "1000 IDD", in the order they
+// first appear; an MPR gets one per result, "2000 VOH[0]" and so on, and an
+// FTR holds P or F. Results are scaled like the first record of their test
+// scales them. Under the header line three more rows, labelled UNITS,
+// LO_LIMIT and HI_LIMIT in the first column, give the units and limits of
+// the tests as the first record of each test has them.
+//
+// r is read twice, once to find the tests and once to write the rows, so
+// memory use grows with the number of tests and sites, not with the
+// number of parts. If r cannot seek, as a pipe cannot, it is first copied
+// to a temporary file, which takes as much disk space as the stream.
+// Results that do not belong to an open part, or that are marked invalid,
+// are left out.
+func ExportCSV(w io.Writer, r io.Reader) error {
+	rs, ok := r.(io.ReadSeeker)
+	var start int64
+	if ok {
+		var err error
+		// os.Stdin is a ReadSeeker, but fails to seek on a pipe
+		start, err = rs.Seek(0, io.SeekCurrent)
+		ok = err == nil
+	}
+	if !ok {
+		f, err := os.CreateTemp("", "stdf-csv-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		if _, err := io.Copy(f, r); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		rs = f
+	}
+	l := newCSVLayout()
+	if err := l.scan(rs, nil); err != nil {
+		return err
+	}
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return err
+	}
+	l.fixed = true
+
+	bw := bufio.NewWriter(w)
+	cw := csv.NewWriter(bw)
+	n := len(csvPartColumns)
+	header := append(append([]string(nil), csvPartColumns...), make([]string, len(l.cols))...)
+	for i, c := range l.cols {
+		header[n+i] = c.name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, row := range []struct {
+		label string
+		get   func(c *csvColumn) string
+	}{
+		{"UNITS", func(c *csvColumn) string { return c.units }},
+		{"LO_LIMIT", func(c *csvColumn) string { return c.lo }},
+		{"HI_LIMIT", func(c *csvColumn) string { return c.hi }},
+	} {
+		line := make([]string, n+len(l.cols))
+		line[0] = row.label
+		for i := range l.cols {
+			line[n+i] = row.get(&l.cols[i])
+		}
+		if err := cw.Write(line); err != nil {
+			return err
+		}
+	}
+	if err := l.scan(rs, cw); err != nil {
+		return err
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// scan reads the records of r. Until the layout is fixed it adds the
+// columns of the tests it meets; after that it writes a row for every part
+// to cw.
+func (l *csvLayout) scan(r io.Reader, cw *csv.Writer) error {
+	rd := NewReader(r)
+	var lot, wafer string
+	open := make(map[[2]U1][]string)
+	n := len(csvPartColumns)
+	for {
+		rec, err := rd.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch rec := rec.(type) {
+		case *MIR:
+			lot = string(rec.LOT_ID)
+		case *WIR:
+			wafer = string(rec.WAFER_ID)
+		case *WRR:
+			wafer = ""
+		case *PIR:
+			if l.fixed {
+				open[[2]U1{rec.HEAD_NUM, rec.SITE_NUM}] = make([]string, n+len(l.cols))
+			}
+		case *PTR:
+			row := open[[2]U1{rec.HEAD_NUM, rec.SITE_NUM}]
+			res, valid := rec.Result()
+			i, ok := l.column(l.key(rec.TEST_NUM, rec.TEST_TXT, -1), func() csvColumn {
+				lo, hasLo := rec.LoLimit()
+				hi, hasHi := rec.HiLimit()
+				return measureColumn(res, lo, hasLo, hi, hasHi)
+			})
+			if ok && row != nil && valid {
+				row[n+i] = l.cols[i].format(res.Value)
+			}
+		case *MPR:
+			row := open[[2]U1{rec.HEAD_NUM, rec.SITE_NUM}]
+			results, valid := rec.Results()
+			for k, m := range results {
+				i, ok := l.column(l.key(rec.TEST_NUM, rec.TEST_TXT, k), func() csvColumn {
+					lo, hasLo := rec.LoLimit()
+					hi, hasHi := rec.HiLimit()
+					return measureColumn(m, lo, hasLo, hi, hasHi)
+				})
+				if ok && row != nil && valid {
+					row[n+i] = l.cols[i].format(m.Value)
+				}
+			}
+		case *FTR:
+			row := open[[2]U1{rec.HEAD_NUM, rec.SITE_NUM}]
+			i, ok := l.column(l.key(rec.TEST_NUM, rec.TEST_TXT, -1), func() csvColumn { return csvColumn{} })
+			if f := rec.TEST_FLG; ok && row != nil && f.PassFailValid() {
+				row[n+i] = "P"
+				if f.Failed() {
+					row[n+i] = "F"
+				}
+			}
+		case *PRR:
+			site := [2]U1{rec.HEAD_NUM, rec.SITE_NUM}
+			row := open[site]
+			if row == nil {
+				continue
+			}
+			delete(open, site)
+			copy(row, []string{
+				lot, wafer,
+				strconv.Itoa(int(rec.HEAD_NUM)), strconv.Itoa(int(rec.SITE_NUM)),
+				string(rec.PART_ID),
+				csvOptional(rec, "X_COORD", int64(rec.X_COORD)),
+				csvOptional(rec, "Y_COORD", int64(rec.Y_COORD)),
+				strconv.Itoa(int(rec.HARD_BIN)),
+				csvOptional(rec, "SOFT_BIN", int64(rec.SOFT_BIN)),
+				csvOptional(rec, "TEST_T", int64(rec.TEST_T)),
+			})
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// csvOptional formats v, the value of the named field of rec, or returns
+// "" if the field is missing.
+func csvOptional(rec StdfRecordType, name string, v int64) string {
+	if rec.IsMissing(name) {
+		return ""
+	}
+	return strconv.FormatInt(v, 10)
+}
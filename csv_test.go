@@ -0,0 +1,78 @@
+package stdf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestExportCSV(t *testing.T) {
+	var bin bytes.Buffer
+	w := NewWriter(&bin)
+	for _, rec := range []StdfRecordType{
+		FAR{Cpu_Type: 2, Stdf_Ver: 4},
+		MIR{SETUP_T: 1709300700, START_T: 1709300760, MODE_COD: 'P', RTST_COD: ' ', PROT_COD: ' ',
+			BURN_TIM: 65535, CMOD_COD: ' ', LOT_ID: CN("L1"), PART_TYP: CN("X"), NODE_NAM: CN("n"),
+			TSTR_TYP: CN("t"), JOB_NAM: CN("j")},
+		WIR{HEAD_NUM: 1, SITE_GRP: 255, START_T: 1709300800, WAFER_ID: CN("W1")},
+		PIR{HEAD_NUM: 1, SITE_NUM: 1},
+		PIR{HEAD_NUM: 1, SITE_NUM: 2},
+		PTR{TEST_NUM: 3, HEAD_NUM: 1, SITE_NUM: 1, RESULT: 0.0012, TEST_TXT: CN("IDD"), OPT_FLAG: 0x0e,
+			RES_SCAL: 3, LLM_SCAL: 3, HLM_SCAL: 3, LO_LIMIT: 0.001, HI_LIMIT: 0.002, UNITS: CN("A")},
+		PTR{TEST_NUM: 3, HEAD_NUM: 1, SITE_NUM: 2, TEST_FLG: 0x80, RESULT: 0.0025},
+		MPR{TEST_NUM: 7, HEAD_NUM: 1, SITE_NUM: 2, RSLT_CNT: 2, RTN_RSLT: KXR4{1.5, -0.5},
+			TEST_TXT: CN("VOH"), OPT_FLAG: 0x4e, HI_LIMIT: 2, UNITS: CN("V")},
+		FTR{TEST_NUM: 9, HEAD_NUM: 1, SITE_NUM: 1, TEST_FLG: 0x80, TEST_TXT: CN("func, fast")},
+		PTR{TEST_NUM: 3, HEAD_NUM: 2, SITE_NUM: 1, RESULT: 1},
+		PRR{HEAD_NUM: 1, SITE_NUM: 2, PART_FLG: 0x08, NUM_TEST: 2, HARD_BIN: 5, SOFT_BIN: 65535,
+			X_COORD: 4, Y_COORD: -5, PART_ID: CN("2")},
+		PRR{HEAD_NUM: 1, SITE_NUM: 1, NUM_TEST: 2, HARD_BIN: 1, SOFT_BIN: 1, X_COORD: -32768,
+			Y_COORD: -32768, TEST_T: 120, PART_ID: CN("1")},
+		WRR{HEAD_NUM: 1, SITE_GRP: 255, FINISH_T: 1709304000, PART_CNT: 2, RTST_CNT: 4294967295,
+			ABRT_CNT: 4294967295, GOOD_CNT: 1, FUNC_CNT: 4294967295, WAFER_ID: CN("W1")},
+		MRR{FINISH_T: 1709304300, DISP_COD: ' '},
+	} {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := ExportCSV(&out, bytes.NewReader(bin.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	want := `LOT_ID,WAFER_ID,HEAD_NUM,SITE_NUM,PART_ID,X_COORD,Y_COORD,HARD_BIN,SOFT_BIN,TEST_T,3 IDD,7 VOH[0],7 VOH[1],"9 func, fast"
+UNITS,,,,,,,,,,mA,V,V,
+LO_LIMIT,,,,,,,,,,1,,,
+HI_LIMIT,,,,,,,,,,2,2,2,
+L1,W1,1,2,2,4,-5,5,,,2.5,1.5,-0.5,
+L1,W1,1,1,1,,,1,1,120,1.2,,,F
+`
+	if got := out.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// streams that cannot seek: a plain Reader and a pipe
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		pw.Write(bin.Bytes())
+		pw.Close()
+	}()
+	defer pr.Close()
+	for _, r := range []io.Reader{struct{ io.Reader }{bytes.NewReader(bin.Bytes())}, pr} {
+		out.Reset()
+		if err := ExportCSV(&out, r); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != want {
+			t.Errorf("from %T got\n%s\nwant\n%s", r, got, want)
+		}
+	}
+}
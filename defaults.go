@@ -0,0 +1,101 @@
+package stdf
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+var typePTR = reflect.TypeOf(PTR{})
+
+// PTRDefaults carries the default data of parametric tests from the first
+// PTR of each test number to the later ones.
+//
+// The first PTR of a test has the fields following OPT_FLAG filled in; later
+// PTRs with the same TEST_NUM may leave them out or blank and take them from
+// the first one. A Reader resolves its PTRs this way on its own. The zero
+// value is ready to use.
+type PTRDefaults struct {
+	first map[U4]*PTR
+}
+
+// Resolve fills in the default data p leaves out from the first PTR of its
+// test. If p is the first PTR of its test it is remembered instead.
+//
+// Fields after OPT_FLAG that p does not have, and C*n fields it has empty,
+// are copied. So are a scaling exponent and limit that p's OPT_FLAG marks
+// invalid (bits 0, 4 and 5) while the first PTR has them valid. Whenever
+// RES_SCAL or a limit is copied, its OPT_FLAG bit is copied along with it,
+// so a limit the first PTR has invalid stays invalid. Limits p says do not
+// exist (bits 6 and 7) are left alone. Fields p takes count as present as far
+// as the first PTR has them, so writing p makes it no longer than that.
+func (d *PTRDefaults) Resolve(p *PTR) {
+	def, ok := d.first[p.TEST_NUM]
+	if !ok {
+		if d.first == nil {
+			d.first = make(map[U4]*PTR)
+		}
+		first := *p
+		d.first[p.TEST_NUM] = &first
+		return
+	}
+	c, err := codecFor(typePTR)
+	if err != nil {
+		return
+	}
+	n := c.present(unsafe.Pointer(p))
+	has := func(name string) bool { return n < 0 || c.index[name] < n }
+
+	if !has("OPT_FLAG") {
+		p.OPT_FLAG = def.OPT_FLAG
+	}
+	if !has("RES_SCAL") || p.OPT_FLAG.ResScaleInvalid() && !def.OPT_FLAG.ResScaleInvalid() {
+		p.RES_SCAL = def.RES_SCAL
+		p.OPT_FLAG = p.OPT_FLAG&^0x01 | def.OPT_FLAG&0x01
+	}
+	// an invalid limit is taken from the default, unless there is no limit
+	lo := p.OPT_FLAG.LowLimitInvalid() && !p.OPT_FLAG.NoLowLimit() && def.OPT_FLAG.HasLowLimit()
+	if !has("LLM_SCAL") || lo {
+		p.LLM_SCAL = def.LLM_SCAL
+	}
+	if !has("LO_LIMIT") || lo {
+		p.LO_LIMIT = def.LO_LIMIT
+		p.OPT_FLAG = p.OPT_FLAG&^0x10 | def.OPT_FLAG&0x10
+	}
+	hi := p.OPT_FLAG.HighLimitInvalid() && !p.OPT_FLAG.NoHighLimit() && def.OPT_FLAG.HasHighLimit()
+	if !has("HLM_SCAL") || hi {
+		p.HLM_SCAL = def.HLM_SCAL
+	}
+	if !has("HI_LIMIT") || hi {
+		p.HI_LIMIT = def.HI_LIMIT
+		p.OPT_FLAG = p.OPT_FLAG&^0x20 | def.OPT_FLAG&0x20
+	}
+	for _, f := range []struct {
+		name    string
+		to, def *CN
+	}{
+		{"UNITS", &p.UNITS, &def.UNITS},
+		{"C_RESFMT", &p.C_RESFMT, &def.C_RESFMT},
+		{"C_LLMFMT", &p.C_LLMFMT, &def.C_LLMFMT},
+		{"C_HLMFMT", &p.C_HLMFMT, &def.C_HLMFMT},
+	} {
+		if !has(f.name) || len(*f.to) == 0 {
+			*f.to = *f.def
+		}
+	}
+	if !has("LO_SPEC") {
+		p.LO_SPEC = def.LO_SPEC
+	}
+	if !has("HI_SPEC") {
+		p.HI_SPEC = def.HI_SPEC
+	}
+	if n >= 0 && n < len(c.fields) {
+		// the defaults are part of the record now, as far as the first PTR
+		// had them; one that was not decoded is trimmed as a new record is
+		switch d := c.present(unsafe.Pointer(def)); {
+		case d < 0:
+			p.present = 0
+		case d > n:
+			p.present = d + 1
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package stdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestReaderPTRDefaults(t *testing.T) {
+	var buf bytes.Buffer
+	for _, rec := range []StdfRecordType{
+		FAR{Cpu_Type: 2, Stdf_Ver: 4},
+		MIR{},
+		PTR{TEST_NUM: 5, RESULT: 1, OPT_FLAG: 0x02, RES_SCAL: -3, LLM_SCAL: -3, HLM_SCAL: -3,
+			LO_LIMIT: 0.5, HI_LIMIT: 1.5, UNITS: CN("V"), LO_SPEC: 0.25, HI_SPEC: 2},
+		PTR{TEST_NUM: 5, RESULT: 2},
+		// an own low limit and a high limit marked invalid
+		PTR{TEST_NUM: 5, RESULT: 3, OPT_FLAG: 0x22, LO_LIMIT: 0.75, HI_LIMIT: 9},
+		MRR{},
+	} {
+		typ, sub, _ := RecordCode(rec)
+		b, err := encodeRecord(typ, sub, rec, binary.LittleEndian)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p, ok := rec.(PTR); ok && p.RESULT == 2 {
+			// only up to RESULT, the rest is default data
+			b = b[:4+12]
+			b[0] = 12
+		}
+		buf.Write(b)
+	}
+
+	r := NewReader(&buf)
+	var ptrs []*PTR
+	for {
+		rec, err := r.Next()
+		if err != nil {
+			break
+		}
+		if p, ok := rec.(*PTR); ok {
+			ptrs = append(ptrs, p)
+		}
+	}
+	if len(ptrs) != 3 {
+		t.Fatalf("got %d PTRs, want 3", len(ptrs))
+	}
+	p := ptrs[1]
+	if p.RESULT != 2 || p.OPT_FLAG != 0x02 || p.RES_SCAL != -3 || p.LO_LIMIT != 0.5 || p.HI_LIMIT != 1.5 ||
+		string(p.UNITS) != "V" || p.HI_SPEC != 2 || p.IsMissing("HI_LIMIT") {
+		t.Errorf("defaults not applied: %+v", p)
+	}
+	p = ptrs[2]
+	if p.LO_LIMIT != 0.75 || p.HI_LIMIT != 1.5 || p.OPT_FLAG != 0x02 || string(p.UNITS) != "V" {
+		t.Errorf("defaults not merged: %+v", p)
+	}
+}
+
+// readPTRs writes recs as a stream, cutting the payload of every PTR whose
+// RESULT is 2 to TEST_NUM .. RESULT, and returns the PTRs read back from it.
+func readPTRs(t *testing.T, resolve bool, recs ...StdfRecordType) []*PTR {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, rec := range recs {
+		typ, sub, _ := RecordCode(rec)
+		b, err := encodeRecord(typ, sub, rec, binary.LittleEndian)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p, ok := rec.(PTR); ok && p.RESULT == 2 {
+			b = b[:4+12]
+			b[0] = 12
+		}
+		buf.Write(b)
+	}
+	r := NewReader(&buf)
+	r.ResolveDefaults(resolve)
+	var ptrs []*PTR
+	for {
+		rec, err := r.Next()
+		if err != nil {
+			break
+		}
+		if p, ok := rec.(*PTR); ok {
+			ptrs = append(ptrs, p)
+		}
+	}
+	return ptrs
+}
+
+func TestPTRDefaultsInvalidBits(t *testing.T) {
+	// the first PTR has RES_SCAL and LO_LIMIT invalid, and they stay so
+	ptrs := readPTRs(t, true,
+		FAR{Cpu_Type: 2, Stdf_Ver: 4},
+		PTR{TEST_NUM: 5, RESULT: 1, OPT_FLAG: 0x13, LO_LIMIT: 0.5, HI_LIMIT: 1.5},
+		PTR{TEST_NUM: 5, RESULT: 2},
+	)
+	if len(ptrs) != 2 {
+		t.Fatalf("got %d PTRs, want 2", len(ptrs))
+	}
+	if p := ptrs[1]; p.OPT_FLAG != 0x13 || p.HI_LIMIT != 1.5 {
+		t.Errorf("OPT_FLAG %#x, HI_LIMIT %v, want 0x13, 1.5", p.OPT_FLAG, p.HI_LIMIT)
+	}
+}
+
+func TestPTRDefaultsLength(t *testing.T) {
+	// the first PTR ends after UNITS, and the second takes no more than that
+	first := PTR{TEST_NUM: 5, RESULT: 1, OPT_FLAG: 0x0e, LO_LIMIT: 0.5, HI_LIMIT: 1.5, UNITS: CN("V")}
+	ptrs := readPTRs(t, true, FAR{Cpu_Type: 2, Stdf_Ver: 4}, first, PTR{TEST_NUM: 5, RESULT: 2})
+	if len(ptrs) != 2 {
+		t.Fatalf("got %d PTRs, want 2", len(ptrs))
+	}
+	want, _ := TransS2B(first)
+	got, err := TransS2B(ptrs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) || string(ptrs[1].UNITS) != "V" || ptrs[1].IsMissing("HI_LIMIT") {
+		t.Errorf("resolved PTR is %d bytes, want %d: %+v", len(got), len(want), ptrs[1])
+	}
+	if !ptrs[1].IsMissing("LO_SPEC") {
+		t.Errorf("LO_SPEC present after resolving")
+	}
+
+	// defaults from a PTR that was not decoded
+	var d PTRDefaults
+	d.Resolve(&PTR{TEST_NUM: 5, OPT_FLAG: 0x0e, UNITS: CN("V")})
+	p := ptrs[1]
+	p.present = 4 + 1
+	d.Resolve(p)
+	if got, _ := TransS2B(p); len(got) != len(want) {
+		t.Errorf("resolved from a new PTR: %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestReaderResolveDefaultsOff(t *testing.T) {
+	var in bytes.Buffer
+	w := NewWriter(&in)
+	for _, rec := range []StdfRecordType{
+		FAR{Cpu_Type: 2, Stdf_Ver: 4},
+		MIR{},
+		PTR{TEST_NUM: 5, RESULT: 1, OPT_FLAG: 0x02, LO_LIMIT: 0.5, HI_LIMIT: 1.5, UNITS: CN("V")},
+		PTR{TEST_NUM: 5, RESULT: 2},
+		MRR{},
+	} {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(bytes.NewReader(in.Bytes()))
+	r.ResolveDefaults(false)
+	var out bytes.Buffer
+	w = NewWriter(&out)
+	for {
+		rec, err := r.Next()
+		if err != nil {
+			break
+		}
+		if p, ok := rec.(*PTR); ok && p.RESULT == 2 && !p.IsMissing("UNITS") {
+			t.Errorf("PTR resolved: %+v", p)
+		}
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), in.Bytes()) {
+		t.Errorf("copy is %d bytes, want %d", out.Len(), in.Len())
+	}
+}
@@ -0,0 +1,51 @@
+package stdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrShortRecord means the data ended inside a field or a record header.
+	ErrShortRecord = errors.New("stdf: short record")
+	// ErrBadLength means a length byte or an array count runs past the end
+	// of the record.
+	ErrBadLength = errors.New("stdf: bad length")
+	// ErrUnknownRecord means the REC_TYP/REC_SUB pair is not an STDF V4 record.
+	ErrUnknownRecord = errors.New("stdf: unknown record type")
+)
+
+// DecodeError describes where decoding a record failed. Err is one of
+// ErrShortRecord, ErrBadLength, ErrUnknownRecord or another underlying
+// error such as io.ErrUnexpectedEOF, and can be tested with errors.Is.
+type DecodeError struct {
+	// Byte offset of the failing data. It is counted from the start of the
+	// stream for errors returned by Reader, from the start of the record
+	// header for DecodeRecord and from the start of the payload for TransB2S.
+	Offset int64
+	// Record type and sub-type of the failing record
+	Rec_Type U1
+	Rec_Sub  U1
+	// Record field being decoded, e.g. "PTR.RESULT", if any
+	Field string
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	s := fmt.Sprintf("%v: record %d/%d at offset %d", e.Err, e.Rec_Type, e.Rec_Sub, e.Offset)
+	if e.Field != "" {
+		s += " in " + e.Field
+	}
+	return s
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// shiftOffset moves the offset of a *DecodeError err by n bytes.
+func shiftOffset(err error, n int64) error {
+	var de *DecodeError
+	if errors.As(err, &de) {
+		de.Offset += n
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+package stdf
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeRecordErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		b      []byte
+		err    error
+		offset int64
+	}{
+		{"short header", []byte{2, 0, 0}, ErrShortRecord, 3},
+		{"short payload", []byte{2, 0, 0, 10, 2}, ErrShortRecord, 5},
+		{"unknown record", []byte{0, 0, 180, 1}, ErrUnknownRecord, 2},
+		{"U2 past end", []byte{5, 0, 1, 40, 1, 1, 3, 0, 9}, ErrShortRecord, 8},
+		{"C*n past end", []byte{6, 0, 0, 20, 1, 0, 0, 0, 5, 'x'}, ErrBadLength, 9},
+		{"kxU2 past end", []byte{4, 0, 1, 70, 3, 0, 1, 0}, ErrBadLength, 6},
+		{"bad V*n code", []byte{4, 0, 50, 10, 1, 0, 9, 0}, nil, 6},
+	}
+	for _, tt := range tests {
+		_, err := DecodeRecord(tt.b)
+		var de *DecodeError
+		if !errors.As(err, &de) {
+			t.Errorf("%s: got %v, want a *DecodeError", tt.name, err)
+			continue
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		}
+		if de.Offset != tt.offset {
+			t.Errorf("%s: got offset %d, want %d", tt.name, de.Offset, tt.offset)
+		}
+	}
+
+	if rec := NewStdfRecord([]byte{1}); rec != nil {
+		t.Errorf("NewStdfRecord on a short header = %T, want nil", rec)
+	}
+}
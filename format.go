@@ -0,0 +1,462 @@
+package stdf
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// recordOf returns the codec of the record o1 (a struct or a pointer to
+// one) and a pointer to it, copying o1 if it is not a pointer.
+func recordOf(o1 interface{}) (*recordCodec, reflect.Value, error) {
+	v := reflect.ValueOf(o1)
+	if v.Kind() != reflect.Ptr {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p
+	}
+	if v.Elem().Kind() != reflect.Struct {
+		return nil, v, fmt.Errorf("stdf: %T is not a record struct", o1)
+	}
+	c, err := codecFor(v.Elem().Type())
+	return c, v, err
+}
+
+// blank reports whether field i of the record at p was left out of the
+// record or holds its Missing/Invalid Data Flag.
+func (c *recordCodec) blank(p unsafe.Pointer, i int) bool {
+	if n := c.present(p); n >= 0 && i >= n {
+		return true
+	}
+	return c.fields[i].missing.unset(p, &c.fields[i])
+}
+
+// recordString formats every field of the record o1 under its spec name,
+// e.g. `MIR SETUP_T=2024-03-01 13:45:00 ... LOT_ID="L1" BURN_TIM=missing`.
+// C*n fields are quoted, byte fields are hex and fields that are missing
+// or hold their Missing/Invalid Data Flag read "missing".
+func recordString(o1 interface{}) string {
+	c, v, err := recordOf(o1)
+	if err != nil {
+		return err.Error()
+	}
+	p := unsafe.Pointer(v.Pointer())
+	var b strings.Builder
+	b.WriteString(c.name)
+	for i := range c.fields {
+		f := &c.fields[i]
+		b.WriteString(" " + f.name + "=")
+		if c.blank(p, i) {
+			b.WriteString("missing")
+			continue
+		}
+		b.WriteString(valueString(v.Elem().Field(f.field)))
+	}
+	return b.String()
+}
+
+func valueString(v reflect.Value) string {
+	switch x := v.Interface().(type) {
+	case CN:
+		return strconv.Quote(string(x))
+	case C12:
+		return strconv.Quote(string(x[:]))
+	case C1:
+		return strconv.QuoteRune(rune(x))
+	case BN:
+		return hex.EncodeToString(x)
+	case CF:
+		return hex.EncodeToString(x)
+	case B6:
+		return hex.EncodeToString(x[:])
+	case DN:
+		return fmt.Sprintf("%d:%s", x.Bits, hex.EncodeToString(x.Data))
+	case Timestamp:
+		return x.String()
+	case R4:
+		return strconv.FormatFloat(float64(x), 'g', -1, 32)
+	case R8:
+		return strconv.FormatFloat(float64(x), 'g', -1, 64)
+	case GenData:
+		if x.Value == nil {
+			return "pad"
+		}
+		return strings.TrimPrefix(fmt.Sprintf("%T", x.Value), "stdf.") + "(" + valueString(reflect.ValueOf(x.Value)) + ")"
+	}
+	if v.Kind() == reflect.Slice {
+		s := make([]string, v.Len())
+		for i := range s {
+			s[i] = valueString(v.Index(i))
+		}
+		return "[" + strings.Join(s, " ") + "]"
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// marshalRecord encodes the record o1 as a JSON object holding every field
+// under its spec name, in record order. Character fields are strings of one
+// character per byte, read as Latin-1 so that any byte survives; byte fields
+// hex strings, D*n fields {"bits":n,"data":hex}, V*n items {"type":code,
+// "value":v} and timestamps strings as formatted by Timestamp.String.
+// Fields that hold their Missing/Invalid Data Flag are null. Fields a
+// decoded record ends before are left out of the object, so that
+// unmarshalRecord gives back a record of the same length.
+func marshalRecord(o1 interface{}) ([]byte, error) {
+	c, v, err := recordOf(o1)
+	if err != nil {
+		return nil, err
+	}
+	p := unsafe.Pointer(v.Pointer())
+	n := len(c.fields)
+	if k := c.present(p); k >= 0 && k < n {
+		n = k
+	}
+	b := []byte{'{'}
+	for i := range c.fields[:n] {
+		f := &c.fields[i]
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendQuote(b, f.name)
+		b = append(b, ':')
+		if c.blank(p, i) {
+			b = append(b, "null"...)
+			continue
+		}
+		if b, err = appendJSON(b, v.Elem().Field(f.field)); err != nil {
+			return nil, fmt.Errorf("stdf: %s.%s: %w", c.name, f.name, err)
+		}
+	}
+	return append(b, '}'), nil
+}
+
+func appendJSON(b []byte, v reflect.Value) ([]byte, error) {
+	switch x := v.Interface().(type) {
+	case CN:
+		return appendJSONChars(b, x), nil
+	case C12:
+		return appendJSONChars(b, x[:]), nil
+	case C1:
+		return appendJSONChars(b, []byte{byte(x)}), nil
+	case BN:
+		return appendJSONString(b, hex.EncodeToString(x))
+	case CF:
+		return appendJSONString(b, hex.EncodeToString(x))
+	case B6:
+		return appendJSONString(b, hex.EncodeToString(x[:]))
+	case DN:
+		b = append(b, `{"bits":`...)
+		b = strconv.AppendUint(b, uint64(x.Bits), 10)
+		b = append(b, `,"data":"`...)
+		b = append(b, hex.EncodeToString(x.Data)...)
+		return append(b, `"}`...), nil
+	case Timestamp:
+		if x == 0 {
+			return append(b, "null"...), nil
+		}
+		return appendJSONString(b, x.String())
+	case R4:
+		return appendJSONFloat(b, float64(x), 32), nil
+	case R8:
+		return appendJSONFloat(b, float64(x), 64), nil
+	case GenData:
+		b = append(b, `{"type":`...)
+		b = strconv.AppendUint(b, uint64(x.Code), 10)
+		if x.Value != nil {
+			var err error
+			b = append(b, `,"value":`...)
+			if b, err = appendJSON(b, reflect.ValueOf(x.Value)); err != nil {
+				return nil, err
+			}
+		}
+		return append(b, '}'), nil
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		b = append(b, '[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			var err error
+			if b, err = appendJSON(b, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return append(b, ']'), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return strconv.AppendUint(b, v.Uint(), 10), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return strconv.AppendInt(b, v.Int(), 10), nil
+	}
+	return nil, fmt.Errorf("cannot encode %s as JSON", v.Type())
+}
+
+func appendJSONString(b []byte, s string) ([]byte, error) {
+	q, err := json.Marshal(s)
+	return append(b, q...), err
+}
+
+// appendJSONChars appends the bytes of a character field as a JSON string,
+// a character per byte as in Latin-1. Bytes outside printable ASCII are
+// written \u00XX, so the result is ASCII and decodes back to the same
+// bytes, whatever code page the tester used.
+func appendJSONChars(b []byte, p []byte) []byte {
+	const hexDigits = "0123456789abcdef"
+	b = append(b, '"')
+	for _, c := range p {
+		switch {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c < 0x20 || c >= 0x7f:
+			b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+	return append(b, '"')
+}
+
+// decodeJSONChars is the inverse of appendJSONChars.
+func decodeJSONChars(raw json.RawMessage) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	p := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, fmt.Errorf("%q has characters outside Latin-1", s)
+		}
+		p = append(p, byte(r))
+	}
+	return p, nil
+}
+
+// appendJSONFloat appends x, or a string for the values JSON has no
+// number for.
+func appendJSONFloat(b []byte, x float64, bits int) []byte {
+	switch {
+	case math.IsNaN(x):
+		return append(b, `"NaN"`...)
+	case math.IsInf(x, 1):
+		return append(b, `"+Inf"`...)
+	case math.IsInf(x, -1):
+		return append(b, `"-Inf"`...)
+	}
+	return strconv.AppendFloat(b, x, 'g', -1, bits)
+}
+
+// unmarshalRecord is the inverse of marshalRecord: it sets the fields of
+// the record struct o1 points to from a JSON object. Keys that are not
+// fields are ignored, as encoding/json does. A null or left out field is
+// set to its Missing/Invalid Data Flag. The record ends with the last field
+// the object has, null or not, so fields after it are not encoded.
+func unmarshalRecord(data []byte, o1 interface{}) error {
+	c, v, err := recordOf(o1)
+	if err != nil {
+		return err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	p := unsafe.Pointer(v.Pointer())
+	last := -1
+	for i := range c.fields {
+		f := &c.fields[i]
+		raw, ok := m[f.name]
+		fv := v.Elem().Field(f.field)
+		fv.Set(reflect.Zero(fv.Type()))
+		if ok {
+			last = i
+		}
+		if !ok || string(raw) == "null" {
+			f.missing.fill(p, f)
+			continue
+		}
+		if err := decodeJSON(raw, fv); err != nil {
+			return fmt.Errorf("stdf: %s.%s: %w", c.name, f.name, err)
+		}
+	}
+	if c.basic {
+		(*BasicRecordType)(unsafe.Add(p, c.basicOff)).present = last + 2
+	}
+	return nil
+}
+
+// genDataTypes are the V*n value types by data type code.
+var genDataTypes = map[U1]reflect.Type{
+	1:  typeU1,
+	2:  typeU2,
+	3:  typeU4,
+	4:  typeI1,
+	5:  typeI2,
+	6:  typeI4,
+	7:  typeR4,
+	8:  typeR8,
+	10: typeCN,
+	11: typeBN,
+	12: typeDN,
+	13: typeN1,
+}
+
+// decodeJSON sets v from raw, the way appendJSON encoded it.
+func decodeJSON(raw json.RawMessage, v reflect.Value) error {
+	switch v.Type() {
+	case typeCN, typeC12, typeC1:
+		s, err := decodeJSONChars(raw)
+		if err != nil {
+			return err
+		}
+		switch v.Type() {
+		case typeCN:
+			v.Set(reflect.ValueOf(CN(s)))
+		case typeC12:
+			if len(s) > 12 {
+				return fmt.Errorf("%q is longer than 12 bytes", s)
+			}
+			var a C12
+			copy(a[:], s)
+			v.Set(reflect.ValueOf(a))
+		case typeC1:
+			if len(s) != 1 {
+				return fmt.Errorf("%q is not a single character", s)
+			}
+			v.Set(reflect.ValueOf(C1(s[0])))
+		}
+		return nil
+	case typeBN, typeCF, typeB6:
+		b, err := decodeHex(raw)
+		if err != nil {
+			return err
+		}
+		switch v.Type() {
+		case typeBN:
+			v.Set(reflect.ValueOf(BN(b)))
+		case typeCF:
+			v.Set(reflect.ValueOf(CF(b)))
+		case typeB6:
+			if len(b) != 6 {
+				return fmt.Errorf("B*6 needs 6 bytes, has %d", len(b))
+			}
+			var a B6
+			copy(a[:], b)
+			v.Set(reflect.ValueOf(a))
+		}
+		return nil
+	case typeDN:
+		var x struct {
+			Bits U2
+			Data json.RawMessage
+		}
+		if err := json.Unmarshal(raw, &x); err != nil {
+			return err
+		}
+		b, err := decodeHex(x.Data)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(DN{Bits: x.Bits, Data: b}))
+		return nil
+	case typeTimestamp:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		t, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(t.Unix()))
+		return nil
+	case reflect.TypeOf(GenData{}):
+		var x struct {
+			Type  U1
+			Value json.RawMessage
+		}
+		if err := json.Unmarshal(raw, &x); err != nil {
+			return err
+		}
+		g := GenData{Code: x.Type}
+		if x.Type != 0 {
+			t, ok := genDataTypes[x.Type]
+			if !ok {
+				return fmt.Errorf("invalid V*n data type code %d", x.Type)
+			}
+			gv := reflect.New(t).Elem()
+			if err := decodeJSON(x.Value, gv); err != nil {
+				return err
+			}
+			g.Value = gv.Interface()
+		}
+		v.Set(reflect.ValueOf(g))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		var a []json.RawMessage
+		if err := json.Unmarshal(raw, &a); err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), len(a), len(a))
+		for i := range a {
+			if err := decodeJSON(a[i], s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			x, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return err
+			}
+			v.SetFloat(x)
+			return nil
+		}
+		var x float64
+		if err := json.Unmarshal(raw, &x); err != nil {
+			return err
+		}
+		v.SetFloat(x)
+		return nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		var x uint64
+		if err := json.Unmarshal(raw, &x); err != nil {
+			return err
+		}
+		if v.OverflowUint(x) {
+			return fmt.Errorf("%d overflows %s", x, v.Type())
+		}
+		v.SetUint(x)
+		return nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		var x int64
+		if err := json.Unmarshal(raw, &x); err != nil {
+			return err
+		}
+		if v.OverflowInt(x) {
+			return fmt.Errorf("%d overflows %s", x, v.Type())
+		}
+		v.SetInt(x)
+		return nil
+	}
+	return fmt.Errorf("cannot decode %s from JSON", v.Type())
+}
+
+func decodeHex(raw json.RawMessage) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(s)
+}
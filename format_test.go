@@ -0,0 +1,86 @@
+package stdf
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRecordString(t *testing.T) {
+	sdr := SDR{HEAD_NUM: 1, SITE_GRP: 2, SITE_CNT: 2, SITE_NUM: KXU1{0, 1}, HAND_ID: CN("H 1")}
+	got := sdr.String()
+	want := `SDR HEAD_NUM=1 SITE_GRP=2 SITE_CNT=2 SITE_NUM=[0 1] HAND_TYP=missing HAND_ID="H 1"`
+	if !strings.HasPrefix(got, want) {
+		t.Errorf("got  %s\nwant %s...", got, want)
+	}
+	if sdr.ToString() != got {
+		t.Error("ToString differs from String")
+	}
+
+	mir := MIR{SETUP_T: 1709300700, BURN_TIM: 65535, MODE_COD: 'P', LOT_ID: CN("L1")}
+	got = mir.String()
+	for _, want := range []string{"SETUP_T=2024-03-01 13:45:00", "START_T=missing", "MODE_COD='P'",
+		`LOT_ID="L1"`, "BURN_TIM=missing"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%s does not contain %s", got, want)
+		}
+	}
+}
+
+func TestRecordMarshalJSON(t *testing.T) {
+	recs := []StdfRecordType{
+		&PRR{HEAD_NUM: 1, SITE_NUM: 2, PART_FLG: 0x08, SOFT_BIN: 65535, X_COORD: -3, PART_ID: CN("7"),
+			PART_FIX: BN{0xab}},
+		GDR{FLD_CNT: 3, GEN_DATA: VN{{Code: 0}, {Code: 10, Value: CN("x")}, {Code: 7, Value: R4(0.5)}}},
+		FTR{FAIL_PIN: DN{Bits: 9, Data: []byte{0xff, 0x01}}},
+	}
+	wants := []string{
+		`{"HEAD_NUM":1,"SITE_NUM":2,"PART_FLG":8,"NUM_TEST":0,"HARD_BIN":0,"SOFT_BIN":null,"X_COORD":-3,` +
+			`"Y_COORD":0,"TEST_T":null,"PART_ID":"7","PART_TXT":null,"PART_FIX":"ab"}`,
+		`{"FLD_CNT":3,"GEN_DATA":[{"type":0},{"type":10,"value":"x"},{"type":7,"value":0.5}]}`,
+		`"FAIL_PIN":{"bits":9,"data":"ff01"}`,
+	}
+	for i, rec := range recs {
+		b, err := json.Marshal(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), wants[i]) {
+			t.Errorf("got  %s\nwant %s", b, wants[i])
+		}
+	}
+}
+
+func TestRecordJSONCharacters(t *testing.T) {
+	// µA in Latin-1, a quote and a control character survive the trip
+	ptr := PTR{TEST_NUM: 1, UNITS: CN{0xb5, 'A'}, TEST_TXT: CN("a\"b\\\x01")}
+	mir := MIR{MODE_COD: 0xb5, LOT_ID: CN("L1")}
+	b, err := json.Marshal(ptr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"UNITS":"\u00b5A"`) {
+		t.Errorf("got %s", b)
+	}
+	var p PTR
+	if err := json.Unmarshal(b, &p); err != nil {
+		t.Fatal(err)
+	}
+	if string(p.UNITS) != string(ptr.UNITS) || string(p.TEST_TXT) != string(ptr.TEST_TXT) {
+		t.Errorf("got UNITS %v, TEST_TXT %q", []byte(p.UNITS), p.TEST_TXT)
+	}
+	b, err = json.Marshal(mir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m MIR
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.MODE_COD != 0xb5 {
+		t.Errorf("MODE_COD %#x, want 0xb5", m.MODE_COD)
+	}
+	if err := json.Unmarshal([]byte(`{"UNITS":"€"}`), &p); err == nil {
+		t.Error("no error for a character outside Latin-1")
+	}
+}
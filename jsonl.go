@@ -0,0 +1,117 @@
+package stdf
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// ExportJSONL writes every record of the STDF stream r to w as a JSON
+// object on a line of its own (JSON Lines). Each object starts with the
+// record's rec_typ, rec_sub and name, followed by its fields as the
+// record's MarshalJSON writes them:
+//
+//	{"rec_typ":0,"rec_sub":10,"name":"FAR","Cpu_Type":2,"Stdf_Ver":4}
+//
+// Records are exported as the stream has them: PTRs are not resolved
+// against the first PTR of their test, and fields a record ends before are
+// left out of its object, so ImportJSONL writes the stream back byte for
+// byte.
+func ExportJSONL(w io.Writer, r io.Reader) error {
+	rd := NewReader(r)
+	rd.ResolveDefaults(false)
+	bw := bufio.NewWriter(w)
+	for {
+		rec, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		typ, sub, _ := RecordCode(rec)
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		line := make([]byte, 0, len(b)+48)
+		line = append(line, `{"rec_typ":`...)
+		line = strconv.AppendUint(line, uint64(typ), 10)
+		line = append(line, `,"rec_sub":`...)
+		line = strconv.AppendUint(line, uint64(sub), 10)
+		line = append(line, `,"name":`...)
+		line = strconv.AppendQuote(line, reflect.TypeOf(rec).Elem().Name())
+		if len(b) > 2 {
+			line = append(line, ',')
+		}
+		line = append(line, b[1:]...)
+		line = append(line, '\n')
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// maxJSONLLine bounds the lines ImportJSONL reads; the largest record, a
+// 65535 byte payload of R*4 items, fits comfortably.
+const maxJSONLLine = 4 << 20
+
+// ImportJSONL reads JSON Lines as written by ExportJSONL from r and writes
+// the records they describe to w as binary STDF, through a Writer. A name
+// that does not match rec_typ and rec_sub is an error. Records are written
+// in the order they come, see Writer.CheckOrder, so a file that breaks the
+// record order of the specification comes back as it was. Blank lines are
+// skipped.
+func ImportJSONL(w io.Writer, r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxJSONLLine)
+	wr := NewWriter(w)
+	wr.CheckOrder(false)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		rec, err := jsonlRecord(line)
+		if err != nil {
+			return fmt.Errorf("stdf: JSONL line %d: %w", n, err)
+		}
+		if err := wr.Write(rec); err != nil {
+			return fmt.Errorf("stdf: JSONL line %d: %w", n, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return wr.Close()
+}
+
+// jsonlRecord decodes one line of ExportJSONL output.
+func jsonlRecord(line []byte) (StdfRecordType, error) {
+	var h struct {
+		Rec_Typ *U1    `json:"rec_typ"`
+		Rec_Sub *U1    `json:"rec_sub"`
+		Name    string `json:"name"`
+	}
+	if err := json.Unmarshal(line, &h); err != nil {
+		return nil, err
+	}
+	if h.Rec_Typ == nil || h.Rec_Sub == nil {
+		return nil, fmt.Errorf("no rec_typ or rec_sub")
+	}
+	rec := NewStdfRecord([]byte{0, 0, byte(*h.Rec_Typ), byte(*h.Rec_Sub)})
+	if rec == nil {
+		return nil, fmt.Errorf("%w: %d/%d", ErrUnknownRecord, *h.Rec_Typ, *h.Rec_Sub)
+	}
+	if name := reflect.TypeOf(rec).Elem().Name(); h.Name != "" && h.Name != name {
+		return nil, fmt.Errorf("name %s does not match record %d/%d (%s)", h.Name, *h.Rec_Typ, *h.Rec_Sub, name)
+	}
+	if err := json.Unmarshal(line, rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
@@ -0,0 +1,109 @@
+package stdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestJSONLRoundTrip(t *testing.T) {
+	var bin bytes.Buffer
+	w := NewWriter(&bin)
+	for _, rec := range []StdfRecordType{
+		FAR{Cpu_Type: 2, Stdf_Ver: 4},
+		MIR{SETUP_T: 1709300700, START_T: 1709300760, MODE_COD: 'P', RTST_COD: ' ', PROT_COD: ' ',
+			BURN_TIM: 65535, CMOD_COD: ' ', LOT_ID: CN("L1"), PART_TYP: CN("X"), NODE_NAM: CN("n"),
+			TSTR_TYP: CN("t"), JOB_NAM: CN("j")},
+		PIR{HEAD_NUM: 1, SITE_NUM: 1},
+		// µA in Latin-1
+		PTR{TEST_NUM: 3, HEAD_NUM: 1, SITE_NUM: 1, RESULT: 0.0012, OPT_FLAG: 0x02, RES_SCAL: 3,
+			LO_LIMIT: -1, HI_LIMIT: 1, UNITS: CN{0xb5, 'A'}, LO_SPEC: -2, HI_SPEC: 2},
+		GDR{FLD_CNT: 2, GEN_DATA: VN{{Code: 10, Value: CN("hello")}, {Code: 5, Value: I2(-7)}}},
+		PRR{HEAD_NUM: 1, SITE_NUM: 1, PART_FLG: 0x08, NUM_TEST: 1, HARD_BIN: 2, SOFT_BIN: 65535,
+			X_COORD: 4, Y_COORD: -5, PART_ID: CN("1")},
+		PIR{HEAD_NUM: 1, SITE_NUM: 1},
+	} {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// a short PTR of TEST_NUM .. RESULT, which leaves the rest to the first
+	// PTR of test 3, and a PRR that has its empty PART_TXT and PART_FIX
+	bin.Write([]byte{12, 0, 15, 10, 3, 0, 0, 0, 1, 1, 0, 0, 0, 0, 0x80, 0x3f})
+	prr, err := (PRR{HEAD_NUM: 1, SITE_NUM: 1, NUM_TEST: 1, HARD_BIN: 1, SOFT_BIN: 1, PART_ID: CN("2")}).ToByte()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prr[0] += 2
+	bin.Write(append(prr, 0, 0))
+	if err := w.Write(MRR{FINISH_T: 1709304300, DISP_COD: ' '}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var jsonl bytes.Buffer
+	if err := ExportJSONL(&jsonl, bytes.NewReader(bin.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(jsonl.String(), "\n"), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("got %d lines:\n%s", len(lines), jsonl.String())
+	}
+	if want := `{"rec_typ":0,"rec_sub":10,"name":"FAR","Cpu_Type":2,"Stdf_Ver":4}`; lines[0] != want {
+		t.Errorf("got  %s\nwant %s", lines[0], want)
+	}
+	if want := `{"rec_typ":15,"rec_sub":10,"name":"PTR","TEST_NUM":3,"HEAD_NUM":1,"SITE_NUM":1,` +
+		`"TEST_FLG":0,"PARM_FLG":0,"RESULT":1}`; lines[7] != want {
+		t.Errorf("got  %s\nwant %s", lines[7], want)
+	}
+
+	var back bytes.Buffer
+	if err := ImportJSONL(&back, strings.NewReader(jsonl.String())); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(back.Bytes(), bin.Bytes()) {
+		t.Errorf("round trip differs:\n got %v\nwant %v", back.Bytes(), bin.Bytes())
+	}
+
+	for _, bad := range []string{
+		`{"rec_typ":0,"rec_sub":10,"name":"MIR","Cpu_Type":2,"Stdf_Ver":4}`,
+		`{"name":"FAR"}`,
+	} {
+		if err := ImportJSONL(&back, strings.NewReader(bad)); err == nil {
+			t.Errorf("no error for %s", bad)
+		}
+	}
+}
+
+func TestJSONLOutOfOrder(t *testing.T) {
+	// a DTR before the MIR and no MRR, as some testers write them
+	var bin bytes.Buffer
+	for _, rec := range []StdfRecordType{
+		FAR{Cpu_Type: 2, Stdf_Ver: 4},
+		DTR{TEXT_DAT: CN("setup")},
+		MIR{LOT_ID: CN("L1")},
+		PIR{HEAD_NUM: 1, SITE_NUM: 1},
+	} {
+		b, err := rec.ToByte()
+		if err != nil {
+			t.Fatal(err)
+		}
+		bin.Write(b)
+	}
+
+	var jsonl, back bytes.Buffer
+	if err := ExportJSONL(&jsonl, bytes.NewReader(bin.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportJSONL(&back, strings.NewReader(jsonl.String())); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(back.Bytes(), bin.Bytes()) {
+		t.Errorf("round trip differs:\n got %v\nwant %v", back.Bytes(), bin.Bytes())
+	}
+}
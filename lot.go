@@ -0,0 +1,140 @@
+package stdf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrPartSequence is matched by the errors ReadLot reports for WIR and WRR
+// records that do not pair up, and by every *PartError.
+var ErrPartSequence = errors.New("stdf: part records out of sequence")
+
+// Lot is an STDF file grouped by what was tested: the lot records, its
+// wafers and their parts.
+type Lot struct {
+	MIR *MIR
+	// RDR is nil unless the lot is a retest of some bins
+	RDR  *RDR
+	SDRs []*SDR
+	// WCR is nil unless the file holds wafer data
+	WCR *WCR
+	// MRR is nil if the file was cut short
+	MRR    *MRR
+	Wafers []*Wafer
+	// Parts tested outside a wafer, as at final test
+	Parts []*Part
+	// Warnings are the problems the PartAssembler found, in stream order
+	Warnings []*PartError
+}
+
+// Wafer is the parts of a wafer, between its WIR and WRR.
+type Wafer struct {
+	WIR *WIR
+	// WRR is nil if the file was cut short
+	WRR   *WRR
+	Parts []*Part
+}
+
+// Part is a tested part: its PIR and PRR, and the PTR, MPR and FTR records
+// of its site in between.
+type Part struct {
+	PIR *PIR
+	// PRR is nil if the file was cut short
+	PRR *PRR
+	// Tests holds *PTR, *MPR and *FTR records in stream order
+	Tests []StdfRecordType
+}
+
+// Test returns the first PTR, MPR or FTR of p with the test number num, or
+// nil if the part has none.
+func (p *Part) Test(num U4) StdfRecordType {
+	for _, rec := range p.Tests {
+		var n U4
+		switch rec := rec.(type) {
+		case *PTR:
+			n = rec.TEST_NUM
+		case *MPR:
+			n = rec.TEST_NUM
+		case *FTR:
+			n = rec.TEST_NUM
+		}
+		if n == num {
+			return rec
+		}
+	}
+	return nil
+}
+
+// ReadLot reads the STDF stream r to its end and groups its records into a
+// Lot.
+//
+// Parts are put together by a PartAssembler, and a part belongs to the
+// wafer its head has open when its PIR comes, if any. The problems the
+// assembler reports do not stop ReadLot: they are kept in Lot.Warnings and
+// the records go where the assembler puts them, so a part on a site no SDR
+// lists is kept, a part its site left without a PRR is kept with a nil
+// PRR, and a test record or PRR outside a part is dropped. A WIR or WRR
+// that does not pair up is an error matching ErrPartSequence. Parts and
+// wafers still open at the end of a truncated file are kept, with a nil
+// PRR or WRR, and the parts are reported in Lot.Warnings too. Records the model has no place for, such as GDRs, DTRs and
+// the summary records, are dropped.
+//
+// The whole file is kept in memory; use a Reader to walk a file too large
+// for that.
+func ReadLot(r io.Reader) (*Lot, error) {
+	rd := NewReader(r)
+	lot := &Lot{}
+	var parts PartAssembler
+	wafers := make(map[U1]*Wafer)
+	for {
+		off := rd.Offset()
+		rec, err := rd.Next()
+		if err == io.EOF {
+			lot.Warnings = append(lot.Warnings, parts.Close()...)
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := parts.Add(rec); err != nil {
+			lot.Warnings = append(lot.Warnings, err.(*PartError))
+		}
+		switch rec := rec.(type) {
+		case *MIR:
+			lot.MIR = rec
+		case *RDR:
+			lot.RDR = rec
+		case *SDR:
+			lot.SDRs = append(lot.SDRs, rec)
+		case *WCR:
+			lot.WCR = rec
+		case *MRR:
+			lot.MRR = rec
+		case *WIR:
+			if wafers[rec.HEAD_NUM] != nil {
+				return nil, fmt.Errorf("%w: WIR on head %d, which has a wafer open, at offset %d",
+					ErrPartSequence, rec.HEAD_NUM, off)
+			}
+			w := &Wafer{WIR: rec}
+			wafers[rec.HEAD_NUM] = w
+			lot.Wafers = append(lot.Wafers, w)
+		case *WRR:
+			w := wafers[rec.HEAD_NUM]
+			if w == nil {
+				return nil, fmt.Errorf("%w: WRR on head %d without a WIR at offset %d",
+					ErrPartSequence, rec.HEAD_NUM, off)
+			}
+			w.WRR = rec
+			delete(wafers, rec.HEAD_NUM)
+		case *PIR:
+			p := parts.Part(rec.HEAD_NUM, rec.SITE_NUM)
+			if w := wafers[rec.HEAD_NUM]; w != nil {
+				w.Parts = append(w.Parts, p)
+			} else {
+				lot.Parts = append(lot.Parts, p)
+			}
+		}
+	}
+	return lot, nil
+}
@@ -0,0 +1,136 @@
+package stdf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func lotStream(t *testing.T, recs ...StdfRecordType) []byte {
+	t.Helper()
+	var bin bytes.Buffer
+	w := NewWriter(&bin)
+	head := []StdfRecordType{
+		FAR{Cpu_Type: 2, Stdf_Ver: 4},
+		MIR{SETUP_T: 1709300700, START_T: 1709300760, MODE_COD: 'P', RTST_COD: ' ', PROT_COD: ' ',
+			BURN_TIM: 65535, CMOD_COD: ' ', LOT_ID: CN("L1"), PART_TYP: CN("X"), NODE_NAM: CN("n"),
+			TSTR_TYP: CN("t"), JOB_NAM: CN("j")},
+		SDR{HEAD_NUM: 1, SITE_GRP: 1, SITE_CNT: 2, SITE_NUM: KXU1{1, 2}},
+	}
+	for _, rec := range append(head, recs...) {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return bin.Bytes()
+}
+
+func TestReadLot(t *testing.T) {
+	b := lotStream(t,
+		WCR{WF_FLAT: 'D', POS_X: 'R', POS_Y: 'U'},
+		WIR{HEAD_NUM: 1, SITE_GRP: 255, WAFER_ID: CN("W1")},
+		PIR{HEAD_NUM: 1, SITE_NUM: 1},
+		PIR{HEAD_NUM: 1, SITE_NUM: 2},
+		PTR{TEST_NUM: 3, HEAD_NUM: 1, SITE_NUM: 2, RESULT: 2},
+		PTR{TEST_NUM: 3, HEAD_NUM: 1, SITE_NUM: 1, RESULT: 1},
+		FTR{TEST_NUM: 4, HEAD_NUM: 1, SITE_NUM: 1},
+		PRR{HEAD_NUM: 1, SITE_NUM: 1, HARD_BIN: 1, PART_ID: CN("1")},
+		DTR{TEXT_DAT: CN("between parts")},
+		PRR{HEAD_NUM: 1, SITE_NUM: 2, HARD_BIN: 2, PART_ID: CN("2")},
+		WRR{HEAD_NUM: 1, SITE_GRP: 255, PART_CNT: 2, WAFER_ID: CN("W1")},
+		PIR{HEAD_NUM: 1, SITE_NUM: 1},
+		PTR{TEST_NUM: 3, HEAD_NUM: 1, SITE_NUM: 1, RESULT: 5},
+		PRR{HEAD_NUM: 1, SITE_NUM: 1, HARD_BIN: 1, PART_ID: CN("3")},
+		MRR{DISP_COD: ' '},
+	)
+	lot, err := ReadLot(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(lot.MIR.LOT_ID) != "L1" || len(lot.SDRs) != 1 || lot.WCR == nil || lot.MRR == nil || lot.RDR != nil {
+		t.Fatalf("unexpected lot records %+v", lot)
+	}
+	if len(lot.Wafers) != 1 || lot.Wafers[0].WRR == nil || len(lot.Wafers[0].Parts) != 2 || len(lot.Parts) != 1 {
+		t.Fatalf("unexpected wafers %+v and parts %+v", lot.Wafers, lot.Parts)
+	}
+	for i, want := range []struct {
+		id     string
+		result R4
+		tests  int
+	}{{"1", 1, 2}, {"2", 2, 1}} {
+		p := lot.Wafers[0].Parts[i]
+		if string(p.PRR.PART_ID) != want.id || len(p.Tests) != want.tests {
+			t.Errorf("part %d: got %s with %d tests", i, p.PRR.PART_ID, len(p.Tests))
+		}
+		if ptr, ok := p.Test(3).(*PTR); !ok || ptr.RESULT != want.result {
+			t.Errorf("part %d: got test 3 %v", i, p.Test(3))
+		}
+	}
+	if p := lot.Parts[0]; string(p.PRR.PART_ID) != "3" || p.Test(4) != nil {
+		t.Errorf("unexpected part %+v", p)
+	}
+
+	if len(lot.Warnings) != 0 {
+		t.Errorf("unexpected warnings %v", lot.Warnings)
+	}
+
+	// a truncated file keeps its open parts and reports them
+	lot, err = ReadLot(bytes.NewReader(lotStream(t, PIR{HEAD_NUM: 1, SITE_NUM: 1})))
+	if err != nil || len(lot.Parts) != 1 || lot.Parts[0].PRR != nil || lot.MRR != nil {
+		t.Errorf("truncated file: got %+v, %v", lot, err)
+	}
+	if len(lot.Warnings) != 1 || !errors.Is(lot.Warnings[0], ErrUnclosedPart) || lot.Warnings[0].Part != lot.Parts[0] {
+		t.Errorf("truncated file: got warnings %v", lot.Warnings)
+	}
+}
+
+func TestReadLotSequence(t *testing.T) {
+	for name, recs := range map[string][]StdfRecordType{
+		"WRR without WIR": {WRR{HEAD_NUM: 1}},
+		"WIR twice":       {WIR{HEAD_NUM: 1}, WIR{HEAD_NUM: 1}},
+	} {
+		if _, err := ReadLot(bytes.NewReader(lotStream(t, recs...))); !errors.Is(err, ErrPartSequence) {
+			t.Errorf("%s: got %v, want ErrPartSequence", name, err)
+		}
+	}
+}
+
+func TestReadLotWarnings(t *testing.T) {
+	for name, tc := range map[string]struct {
+		recs  []StdfRecordType
+		want  []error
+		parts int
+	}{
+		"PRR without PIR": {[]StdfRecordType{PRR{HEAD_NUM: 1, SITE_NUM: 1}}, []error{ErrOrphanRecord}, 0},
+		// the part on site 1 stays open to the end
+		"PTR outside part": {[]StdfRecordType{PIR{HEAD_NUM: 1, SITE_NUM: 1}, PTR{HEAD_NUM: 1, SITE_NUM: 2}},
+			[]error{ErrOrphanRecord, ErrUnclosedPart}, 1},
+		"PIR twice": {[]StdfRecordType{PIR{HEAD_NUM: 1, SITE_NUM: 1}, PIR{HEAD_NUM: 1, SITE_NUM: 1}},
+			[]error{ErrUnclosedPart, ErrUnclosedPart}, 2},
+		"site not in SDR": {[]StdfRecordType{PIR{HEAD_NUM: 1, SITE_NUM: 3}},
+			[]error{ErrUnknownSite, ErrUnclosedPart}, 1},
+	} {
+		recs := append(tc.recs, PIR{HEAD_NUM: 1, SITE_NUM: 2}, PRR{HEAD_NUM: 1, SITE_NUM: 2}, MRR{DISP_COD: ' '})
+		lot, err := ReadLot(bytes.NewReader(lotStream(t, recs...)))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(lot.Warnings) != len(tc.want) {
+			t.Errorf("%s: got warnings %v, want %v", name, lot.Warnings, tc.want)
+			continue
+		}
+		for i, want := range tc.want {
+			if !errors.Is(lot.Warnings[i], want) {
+				t.Errorf("%s: warning %d is %v, want %v", name, i, lot.Warnings[i], want)
+			}
+		}
+		// reading went on after the warning
+		if len(lot.Parts) != tc.parts+1 || lot.Parts[tc.parts].PRR == nil || lot.MRR == nil {
+			t.Errorf("%s: got parts %+v", name, lot.Parts)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package stdf
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"unsafe"
+)
+
+// FieldState tells whether a record field carries a value.
+type FieldState uint8
+
+const (
+	// FieldPresent fields hold a valid value.
+	FieldPresent FieldState = iota
+	// FieldMissing fields were left out: the record ended before them.
+	FieldMissing
+	// FieldInvalid fields are in the record but hold their Missing/Invalid
+	// Data Flag, or are marked invalid by a flag field of the record.
+	FieldInvalid
+)
+
+func (s FieldState) String() string {
+	switch s {
+	case FieldPresent:
+		return "present"
+	case FieldMissing:
+		return "missing"
+	case FieldInvalid:
+		return "invalid"
+	}
+	return "FieldState(" + strconv.Itoa(int(s)) + ")"
+}
+
+// FieldStatus reports whether the field name of rec (a record struct or a
+// pointer to one) was present, left out of the record, or holds its
+// Missing/Invalid Data Flag. Only decoded records can have missing fields.
+// A name that is not a field of rec is reported missing.
+func FieldStatus(rec StdfRecordType, name string) FieldState {
+	c, v, err := recordOf(rec)
+	if err != nil {
+		return FieldMissing
+	}
+	i, ok := c.index[name]
+	if !ok {
+		return FieldMissing
+	}
+	p := unsafe.Pointer(v.Pointer())
+	if n := c.present(p); n >= 0 && i >= n {
+		return FieldMissing
+	}
+	if f := &c.fields[i]; f.missing.invalid(p, f) {
+		return FieldInvalid
+	}
+	return FieldPresent
+}
+
+// missingRule is the Missing/Invalid Data Flag of a field, given in its
+// struct tag:
+//
+//	BURN_TIM U2 `stdf:"missing=65535"`        // a sentinel value
+//	MODE_COD C1 `stdf:"missing=space"`        // a blank character
+//	JOB_REV  CN `stdf:"missing=empty"`        // length byte or count 0
+//	LO_LIMIT R4 `stdf:"flag=OPT_FLAG&0x50"`   // invalid if a bit is set
+//
+// Any of them lets the encoder leave a trailing field out; the flag field
+// comes before the field, so it stays in the record to say so.
+type missingRule struct {
+	sentinel bool
+	value    uint64
+	empty    bool
+	flag     bool
+	flagOff  uintptr
+	flagMask uint8
+}
+
+// unset reports whether the field f of the record at p holds its
+// Missing/Invalid Data Flag.
+func (m *missingRule) unset(p unsafe.Pointer, f *fieldCodec) bool {
+	q := unsafe.Add(p, f.off)
+	switch {
+	case m.sentinel:
+		switch f.kind {
+		case k8:
+			return uint64(*(*uint8)(q)) == m.value
+		case k16:
+			return uint64(*(*uint16)(q)) == m.value
+		case k32:
+			return uint64(*(*uint32)(q)) == m.value
+		case k64:
+			return *(*uint64)(q) == m.value
+		}
+	case m.empty:
+		if f.kind == kDN {
+			return (*DN)(q).Bits == 0
+		}
+		return len(*(*[]struct{})(q)) == 0
+	}
+	return false
+}
+
+// fill sets the field f of the record at p to its sentinel, if it has one.
+func (m *missingRule) fill(p unsafe.Pointer, f *fieldCodec) {
+	if !m.sentinel {
+		return
+	}
+	q := unsafe.Add(p, f.off)
+	switch f.kind {
+	case k8:
+		*(*uint8)(q) = uint8(m.value)
+	case k16:
+		*(*uint16)(q) = uint16(m.value)
+	case k32:
+		*(*uint32)(q) = uint32(m.value)
+	case k64:
+		*(*uint64)(q) = m.value
+	}
+}
+
+// invalid is unset, extended to fields marked invalid by a flag field.
+func (m *missingRule) invalid(p unsafe.Pointer, f *fieldCodec) bool {
+	if m.flag && *(*uint8)(unsafe.Add(p, m.flagOff))&m.flagMask != 0 {
+		return true
+	}
+	return m.unset(p, f)
+}
+
+// compileMissing works out the missingRule of field i of t from its tag.
+func compileMissing(t reflect.Type, i int) (missingRule, error) {
+	var m missingRule
+	sf := t.Field(i)
+	kind := fieldKinds[sf.Type]
+	fail := func(format string, a ...interface{}) (missingRule, error) {
+		return m, fmt.Errorf("stdf: %s.%s: "+format, append([]interface{}{t.Name(), sf.Name}, a...)...)
+	}
+	switch v := tagValue(sf.Tag, "missing"); {
+	case v == "":
+	case v == "empty":
+		if kind < kCN || kind == kFix {
+			return fail("missing=empty on a fixed size field")
+		}
+		m.empty = true
+	case v == "space":
+		if kind != k8 {
+			return fail("missing=space on a field that is not a character")
+		}
+		m.sentinel, m.value = true, ' '
+	default:
+		x, err := sentinelBits(sf.Type, v)
+		if err != nil {
+			return fail("missing=%s: %v", v, err)
+		}
+		m.sentinel, m.value = true, x
+	}
+	if v := tagValue(sf.Tag, "flag"); v != "" {
+		name, mask, ok := cut(v, "&")
+		if !ok {
+			return fail("flag=%s is not FIELD&MASK", v)
+		}
+		x, err := strconv.ParseUint(mask, 0, 8)
+		if err != nil {
+			return fail("flag=%s: %v", v, err)
+		}
+		ff, ok := t.FieldByName(name)
+		if !ok || ff.Index[0] >= i || ff.Type.Kind() != reflect.Uint8 {
+			return fail("flag field %s is not a byte before it", name)
+		}
+		m.flag, m.flagOff, m.flagMask = true, ff.Offset, uint8(x)
+	}
+	return m, nil
+}
+
+// sentinelBits parses the sentinel s of a numeric field of type t and
+// returns it as the field's raw bits.
+func sentinelBits(t reflect.Type, s string) (uint64, error) {
+	bits := int(t.Size()) * 8
+	switch t.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return strconv.ParseUint(s, 10, bits)
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		x, err := strconv.ParseInt(s, 10, bits)
+		return uint64(x) & (1<<uint(bits) - 1), err
+	case reflect.Float32:
+		x, err := strconv.ParseFloat(s, 32)
+		return uint64(math.Float32bits(float32(x))), err
+	case reflect.Float64:
+		x, err := strconv.ParseFloat(s, 64)
+		return math.Float64bits(x), err
+	}
+	return 0, fmt.Errorf("%s has no numeric sentinel", t)
+}
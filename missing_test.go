@@ -0,0 +1,109 @@
+package stdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFieldStatus(t *testing.T) {
+	// MIR that stops after MODE_COD
+	b := make([]byte, 4+4+4+1+1)
+	b[4] = 1
+	b[4+4+4+1] = ' '
+	var mir MIR
+	if err := TransB2S(b[4:], &mir); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]FieldState{
+		"SETUP_T":  FieldPresent,
+		"MODE_COD": FieldInvalid,
+		"RTST_COD": FieldMissing,
+		"BURN_TIM": FieldMissing,
+		"SUPR_NAM": FieldMissing,
+		"NO_FIELD": FieldMissing,
+	} {
+		if got := FieldStatus(&mir, name); got != want {
+			t.Errorf("%s is %v, want %v", name, got, want)
+		}
+	}
+	if !mir.IsMissing("BURN_TIM") || mir.IsMissing("STAT_NUM") {
+		t.Errorf("IsMissing: BURN_TIM %v, STAT_NUM %v", mir.IsMissing("BURN_TIM"), mir.IsMissing("STAT_NUM"))
+	}
+	if mir.BURN_TIM != 65535 || mir.RTST_COD != ' ' {
+		t.Errorf("missing fields not set to their sentinel: BURN_TIM %d, RTST_COD %q", mir.BURN_TIM, mir.RTST_COD)
+	}
+	// re-encoding keeps the record as short as it was
+	got, err := TransS2B(mir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, b[4:]) {
+		t.Errorf("got  %v\nwant %v", got, b[4:])
+	}
+
+	ptr := PTR{TEST_FLG: 0x02, OPT_FLAG: 0x50, HI_LIMIT: 5}
+	for name, want := range map[string]FieldState{
+		"RESULT":   FieldInvalid,
+		"LO_LIMIT": FieldInvalid,
+		"HI_LIMIT": FieldPresent,
+		"UNITS":    FieldInvalid,
+	} {
+		if got := FieldStatus(ptr, name); got != want {
+			t.Errorf("PTR.%s is %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestEncodeOmitsTrailingMissing(t *testing.T) {
+	prr := PRR{HEAD_NUM: 1, SITE_NUM: 2, NUM_TEST: 3, HARD_BIN: 4, SOFT_BIN: 65535,
+		X_COORD: -32768, Y_COORD: -32768}
+	b, err := TransS2B(prr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{1, 2, 0, 3, 0, 4, 0}
+	if !bytes.Equal(b, want) {
+		t.Errorf("got  %v\nwant %v", b, want)
+	}
+
+	// a field after the missing ones keeps them in the record
+	prr.PART_ID = CN("7")
+	b, err = TransS2B(prr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []byte{1, 2, 0, 3, 0, 4, 0, 0xff, 0xff, 0, 0x80, 0, 0x80, 0, 0, 0, 0, 1, '7'}
+	if !bytes.Equal(b, want) {
+		t.Errorf("got  %v\nwant %v", b, want)
+	}
+}
+
+func TestEncodeOmitsTrailingFlagged(t *testing.T) {
+	// no spec limits and no format strings, as a tester writes it
+	ptr := PTR{TEST_NUM: 1, HEAD_NUM: 1, SITE_NUM: 1, RESULT: 1, OPT_FLAG: 0x0e, LO_LIMIT: 0.5, HI_LIMIT: 2,
+		UNITS: CN("V"), LO_SPEC: 0.25, HI_SPEC: 3}
+	b, err := TransS2B(ptr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// TEST_NUM .. UNITS
+	if len(b) != 28 || b[len(b)-2] != 1 || b[len(b)-1] != 'V' {
+		t.Errorf("got %d bytes %v, want 28 ending in UNITS", len(b), b)
+	}
+	var got PTR
+	if err := TransB2S(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if FieldStatus(&got, "UNITS") != FieldPresent || FieldStatus(&got, "LO_SPEC") != FieldMissing {
+		t.Errorf("UNITS %v, LO_SPEC %v", FieldStatus(&got, "UNITS"), FieldStatus(&got, "LO_SPEC"))
+	}
+
+	// a spec limit that is there keeps the fields before it
+	ptr.OPT_FLAG = 0x0a
+	if b, err = TransS2B(ptr); err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 28+3+4 {
+		t.Errorf("got %d bytes, want %d", len(b), 28+3+4)
+	}
+}
@@ -0,0 +1,144 @@
+package stdf
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrOrphanRecord means a PTR, MPR, FTR or PRR came for a site with no
+	// part open.
+	ErrOrphanRecord = errors.New("stdf: record outside a part")
+	// ErrUnclosedPart means a part got no PRR: its site started another
+	// part, or the stream ended.
+	ErrUnclosedPart = errors.New("stdf: part without PRR")
+	// ErrUnknownSite means a PIR came for a site that no SDR lists.
+	ErrUnknownSite = errors.New("stdf: site not in SDR")
+)
+
+// PartError reports a record a PartAssembler could not fit into a part.
+// Err is ErrOrphanRecord, ErrUnclosedPart or ErrUnknownSite; a PartError
+// also matches ErrPartSequence with errors.Is. A PIR on a site no SDR
+// lists that also leaves a part of its site unclosed is reported once,
+// with Err ErrUnknownSite and the part in Part, and matches
+// ErrUnclosedPart as well.
+type PartError struct {
+	Head, Site U1
+	// Record that caused the error, nil at the end of the stream
+	Record StdfRecordType
+	// Part left unclosed, for ErrUnclosedPart
+	Part *Part
+	Err  error
+}
+
+func (e *PartError) Error() string {
+	s := fmt.Sprintf("%v on head %d site %d", e.Err, e.Head, e.Site)
+	if e.Part != nil && e.Err != ErrUnclosedPart {
+		s += ", which has a part without PRR open"
+	}
+	return s
+}
+
+func (e *PartError) Unwrap() error { return e.Err }
+
+// Is reports whether target is ErrPartSequence, which all part errors are,
+// or ErrUnclosedPart for an error that left a part unclosed.
+func (e *PartError) Is(target error) bool {
+	return target == ErrPartSequence || target == ErrUnclosedPart && e.Part != nil
+}
+
+// PartAssembler puts the records of a stream together into parts.
+//
+// Multi-site testers open a part on each site with a PIR, interleave the
+// PTR, MPR and FTR records of all of them and close each with a PRR. The
+// assembler keys open parts by HEAD_NUM and SITE_NUM and hands each part
+// back complete when its PRR comes. Once SDRs have been added, PIRs are
+// checked against the sites they list. Feed it records with Add as a
+// Reader returns them; the zero value is ready to use.
+type PartAssembler struct {
+	open map[[2]U1]*Part
+	// sites the SDRs list, nil until the first SDR
+	sites map[[2]U1]bool
+}
+
+// Add adds rec, a record pointer as returned by Reader. For a PRR it
+// returns the part the PRR closes. Records other than SDR, PIR, PTR, MPR,
+// FTR and PRR are ignored.
+//
+// Problems come back as a *PartError and do not stop the assembler: an
+// orphaned record is dropped, and a PIR on a site with a part open or not
+// listed by the SDRs still starts a new part. The old part of the site is
+// in the error then, also when the site is not listed either.
+func (a *PartAssembler) Add(rec StdfRecordType) (*Part, error) {
+	var site [2]U1
+	switch rec := rec.(type) {
+	case *SDR:
+		if a.sites == nil {
+			a.sites = make(map[[2]U1]bool)
+		}
+		for _, s := range rec.SITE_NUM {
+			a.sites[[2]U1{rec.HEAD_NUM, s}] = true
+		}
+		return nil, nil
+	case *PIR:
+		site = [2]U1{rec.HEAD_NUM, rec.SITE_NUM}
+		if a.open == nil {
+			a.open = make(map[[2]U1]*Part)
+		}
+		old := a.open[site]
+		a.open[site] = &Part{PIR: rec}
+		err := &PartError{Head: site[0], Site: site[1], Record: rec, Part: old, Err: ErrUnclosedPart}
+		if a.sites != nil && !a.sites[site] {
+			err.Err = ErrUnknownSite
+		} else if old == nil {
+			return nil, nil
+		}
+		return nil, err
+	case *PTR:
+		site = [2]U1{rec.HEAD_NUM, rec.SITE_NUM}
+	case *MPR:
+		site = [2]U1{rec.HEAD_NUM, rec.SITE_NUM}
+	case *FTR:
+		site = [2]U1{rec.HEAD_NUM, rec.SITE_NUM}
+	case *PRR:
+		site = [2]U1{rec.HEAD_NUM, rec.SITE_NUM}
+		p := a.open[site]
+		if p == nil {
+			return nil, &PartError{Head: site[0], Site: site[1], Record: rec, Err: ErrOrphanRecord}
+		}
+		delete(a.open, site)
+		p.PRR = rec
+		return p, nil
+	default:
+		return nil, nil
+	}
+	p := a.open[site]
+	if p == nil {
+		return nil, &PartError{Head: site[0], Site: site[1], Record: rec, Err: ErrOrphanRecord}
+	}
+	p.Tests = append(p.Tests, rec)
+	return nil, nil
+}
+
+// Part returns the part open on the given head and site, or nil.
+func (a *PartAssembler) Part(head, site U1) *Part {
+	return a.open[[2]U1{head, site}]
+}
+
+// Close ends the stream. It returns an ErrUnclosedPart error for each part
+// still open, by head and site, and forgets them.
+func (a *PartAssembler) Close() []*PartError {
+	var errs []*PartError
+	for site, p := range a.open {
+		errs = append(errs, &PartError{Head: site[0], Site: site[1], Part: p, Err: ErrUnclosedPart})
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		if errs[i].Head != errs[j].Head {
+			return errs[i].Head < errs[j].Head
+		}
+		return errs[i].Site < errs[j].Site
+	})
+	a.open = nil
+	return errs
+}
@@ -0,0 +1,85 @@
+package stdf
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPartAssembler(t *testing.T) {
+	var a PartAssembler
+	add := func(rec StdfRecordType, want error) *Part {
+		t.Helper()
+		p, err := a.Add(rec)
+		if !errors.Is(err, want) {
+			t.Fatalf("%s: got error %v, want %v", rec.ToString(), err, want)
+		}
+		if err != nil && !errors.Is(err, ErrPartSequence) {
+			t.Errorf("%v does not match ErrPartSequence", err)
+		}
+		return p
+	}
+
+	// before any SDR every site is legal
+	add(&PIR{HEAD_NUM: 2, SITE_NUM: 9}, nil)
+	add(&SDR{HEAD_NUM: 1, SITE_CNT: 4, SITE_NUM: KXU1{0, 1, 2, 3}}, nil)
+	for s := U1(0); s < 4; s++ {
+		add(&PIR{HEAD_NUM: 1, SITE_NUM: s}, nil)
+	}
+	for s := U1(0); s < 4; s++ {
+		add(&PTR{TEST_NUM: 10, HEAD_NUM: 1, SITE_NUM: 3 - s, RESULT: R4(3 - s)}, nil)
+		add(&FTR{TEST_NUM: 11, HEAD_NUM: 1, SITE_NUM: s}, nil)
+	}
+	add(&PTR{TEST_NUM: 10, HEAD_NUM: 1, SITE_NUM: 5}, ErrOrphanRecord)
+	add(&PIR{HEAD_NUM: 1, SITE_NUM: 7}, ErrUnknownSite)
+	add(&DTR{TEXT_DAT: CN("ignored")}, nil)
+	if p := add(&PRR{HEAD_NUM: 1, SITE_NUM: 7}, nil); p == nil || len(p.Tests) != 0 {
+		t.Errorf("site 7: got %+v", p)
+	}
+
+	for s := U1(0); s < 3; s++ {
+		p := add(&PRR{HEAD_NUM: 1, SITE_NUM: s, HARD_BIN: U2(s)}, nil)
+		if p == nil || p.PIR.SITE_NUM != s || p.PRR.HARD_BIN != U2(s) || len(p.Tests) != 2 {
+			t.Fatalf("site %d: got %+v", s, p)
+		}
+		if ptr := p.Test(10).(*PTR); ptr.RESULT != R4(s) {
+			t.Errorf("site %d: got result %v", s, ptr.RESULT)
+		}
+	}
+	add(&PRR{HEAD_NUM: 1, SITE_NUM: 0}, ErrOrphanRecord)
+
+	// a second PIR abandons the open part of site 3
+	var pe *PartError
+	if _, err := a.Add(&PIR{HEAD_NUM: 1, SITE_NUM: 3}); !errors.As(err, &pe) || pe.Err != ErrUnclosedPart ||
+		pe.Part == nil || len(pe.Part.Tests) != 2 {
+		t.Fatalf("got %v", err)
+	}
+	if a.Part(1, 3) == nil || len(a.Part(1, 3).Tests) != 0 {
+		t.Errorf("site 3 has no new part")
+	}
+
+	// and one on a site no SDR lists reports both
+	add(&PIR{HEAD_NUM: 1, SITE_NUM: 7}, ErrUnknownSite)
+	add(&PTR{TEST_NUM: 10, HEAD_NUM: 1, SITE_NUM: 7}, nil)
+	_, err := a.Add(&PIR{HEAD_NUM: 1, SITE_NUM: 7})
+	if !errors.As(err, &pe) || pe.Err != ErrUnknownSite || !errors.Is(err, ErrUnclosedPart) ||
+		pe.Part == nil || len(pe.Part.Tests) != 1 {
+		t.Fatalf("got %v", err)
+	}
+	if want := "stdf: site not in SDR on head 1 site 7, which has a part without PRR open"; err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+	add(&PRR{HEAD_NUM: 1, SITE_NUM: 7}, nil)
+
+	errs := a.Close()
+	if len(errs) != 2 || errs[0].Head != 1 || errs[0].Site != 3 || errs[1].Head != 2 || errs[1].Site != 9 {
+		t.Fatalf("got %v", errs)
+	}
+	for _, e := range errs {
+		if e.Err != ErrUnclosedPart || e.Part == nil || e.Record != nil {
+			t.Errorf("got %+v", e)
+		}
+	}
+	if a.Part(1, 3) != nil {
+		t.Errorf("Close kept the open parts")
+	}
+}
@@ -0,0 +1,102 @@
+package stdf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Reader walks the records of an STDF stream one at a time.
+//
+// Records are decoded with DecodeRecord. Record types it does not recognise
+// are skipped, as the STDF specification asks of readers that meet reserved
+// or vendor specific records.
+//
+// The byte order of the stream is taken from the CPU_TYPE of its FAR, see
+// FAR.ByteOrder; streams that do not start with a FAR are read as
+// little-endian.
+//
+// PTRs come back with the default data of their test filled in, see
+// PTRDefaults, unless that is turned off with ResolveDefaults.
+type Reader struct {
+	r     *bufio.Reader
+	hdr   [4]byte
+	off   int64
+	order binary.ByteOrder
+	ptr   PTRDefaults
+	// PTRs are returned as they are in the stream
+	raw bool
+}
+
+// NewReader returns a Reader that decodes STDF records from r.
+// r is buffered internally, so it can be a pipe, a socket or a file.
+func NewReader(r io.Reader) *Reader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &Reader{r: br, order: binary.LittleEndian}
+}
+
+// Offset returns the stream offset of the next record header.
+func (r *Reader) Offset() int64 { return r.off }
+
+// ByteOrder returns the byte order the stream is decoded with.
+func (r *Reader) ByteOrder() binary.ByteOrder { return r.order }
+
+// ResolveDefaults turns filling in the default data of PTRs on, as it is
+// for a new Reader, or off. With it off PTRs come back with only the fields
+// the stream has, so a Writer writes them out byte for byte, as a filter or
+// a copy of a file should.
+func (r *Reader) ResolveDefaults(on bool) { r.raw = !on }
+
+// Next returns the next decoded record of the stream.
+// At the end of the stream it returns io.EOF. Damaged data is reported as
+// a *DecodeError with its offset in the stream; a stream that stops in the
+// middle of a record wraps io.ErrUnexpectedEOF.
+func (r *Reader) Next() (StdfRecordType, error) {
+	for {
+		start := r.off
+		n, err := io.ReadFull(r.r, r.hdr[:])
+		r.off += int64(n)
+		if err == io.EOF {
+			return nil, err
+		}
+		if err != nil {
+			return nil, &DecodeError{Offset: r.off, Err: err}
+		}
+		if start == 0 && r.hdr[2] == 0 && r.hdr[3] == 10 && r.hdr[0] == 0 && r.hdr[1] != 0 {
+			// a FAR whose REC_LEN only makes sense big-endian; its
+			// CPU_TYPE settles the order below
+			r.order = binary.BigEndian
+		}
+		// decoded CN fields keep referring to b, so every record gets its own buffer
+		b := make([]byte, 4+int(r.order.Uint16(r.hdr[:])))
+		copy(b, r.hdr[:])
+		n, err = io.ReadFull(r.r, b[4:])
+		r.off += int64(n)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, &DecodeError{Offset: r.off, Rec_Type: U1(b[2]), Rec_Sub: U1(b[3]), Err: err}
+		}
+		rec, err := decodeRecord(b, r.order)
+		if errors.Is(err, ErrUnknownRecord) {
+			continue
+		}
+		if err != nil {
+			return nil, shiftOffset(err, start)
+		}
+		switch rec := rec.(type) {
+		case *FAR:
+			r.order = rec.ByteOrder()
+		case *PTR:
+			if !r.raw {
+				r.ptr.Resolve(rec)
+			}
+		}
+		return rec, nil
+	}
+}
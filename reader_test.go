@@ -0,0 +1,119 @@
+package stdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestReaderNext(t *testing.T) {
+	data := []byte{
+		// FAR
+		2, 0, 0, 10, 2, 4,
+		// reserved record, skipped
+		3, 0, 180, 1, 1, 2, 3,
+		// MIR, trailing fields left out
+		11, 0, 1, 10, 1, 0, 0, 0, 2, 0, 0, 0, 7, 'P', 'N',
+	}
+	r := NewReader(bytes.NewReader(data))
+
+	rec, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	far, ok := rec.(*FAR)
+	if !ok || far.Cpu_Type != 2 || far.Stdf_Ver != 4 {
+		t.Fatalf("got %#v, want FAR", rec)
+	}
+
+	rec, err = r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mir, ok := rec.(*MIR)
+	if !ok {
+		t.Fatalf("got %#v, want MIR", rec)
+	}
+	if mir.SETUP_T != 1 || mir.START_T != 2 || mir.STAT_NUM != 7 || mir.MODE_COD != 'P' || mir.RTST_COD != 'N' {
+		t.Errorf("unexpected MIR %s", mir.ToString())
+	}
+
+	if _, err = r.Next(); err != io.EOF {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+}
+
+func TestReaderTruncated(t *testing.T) {
+	r := NewReader(bytes.NewReader([]byte{2, 0, 0, 10, 2}))
+	if _, err := r.Next(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestReaderDecodeErrorOffset(t *testing.T) {
+	data := []byte{
+		2, 0, 0, 10, 2, 4,
+		// PIR
+		2, 0, 5, 10, 1, 1,
+		// DTR whose C*n length runs past the record
+		3, 0, 50, 30, 9, 'a', 'b',
+	}
+	r := NewReader(bytes.NewReader(data))
+	r.Next()
+	r.Next()
+	_, err := r.Next()
+	var de *DecodeError
+	if !errors.As(err, &de) || !errors.Is(err, ErrBadLength) {
+		t.Fatalf("got %v, want a DecodeError wrapping ErrBadLength", err)
+	}
+	if de.Offset != 17 || de.Rec_Type != 50 || de.Rec_Sub != 30 || de.Field != "DTR.TEXT_DAT" {
+		t.Errorf("got %+v", de)
+	}
+}
+
+func TestReaderBigEndian(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	ptr := PTR{TEST_NUM: 0x01020304, RESULT: 1.5, OPT_FLAG: 0x02, LO_LIMIT: -1, HI_LIMIT: 2, UNITS: CN("V")}
+	for _, rec := range []StdfRecordType{
+		FAR{Cpu_Type: 1, Stdf_Ver: 4},
+		MIR{SETUP_T: 0x0a0b0c0d, BURN_TIM: 0x0102},
+		ptr,
+		MRR{},
+	} {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if !bytes.Equal(b[:6], []byte{0, 2, 0, 10, 1, 4}) || !bytes.Equal(b[10:14], []byte{0x0a, 0x0b, 0x0c, 0x0d}) {
+		t.Fatalf("not big-endian: % x", b[:14])
+	}
+
+	r := NewReader(&buf)
+	if _, err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if r.ByteOrder() != binary.BigEndian {
+		t.Fatalf("got byte order %v", r.ByteOrder())
+	}
+	rec, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mir := rec.(*MIR); mir.SETUP_T != 0x0a0b0c0d || mir.BURN_TIM != 0x0102 {
+		t.Errorf("unexpected MIR %+v", mir)
+	}
+	rec, err = r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.(*PTR); got.TEST_NUM != ptr.TEST_NUM || got.RESULT != 1.5 || got.LO_LIMIT != -1 || got.HI_LIMIT != 2 {
+		t.Errorf("unexpected PTR %+v", got)
+	}
+}
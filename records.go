@@ -0,0 +1,1617 @@
+package stdf
+
+// Master Results Record (MRR)
+// Function: The Master Results Record (MRR) is a logical extension of the Master Information
+// Record (MIR). The data can be thought of as belonging with the MIR, but it is not
+// available when the tester writes the MIR information. Each data stream must have
+// exactly one MRR as the last record in the data stream.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (1)
+// REC_SUB U*1 Record sub-type (20)
+// FINISH_T U*4 Date and time last part tested
+// DISP_COD C*1 Lot disposition code space
+// USR_DESC C*n Lot description supplied by user length byte = 0
+// EXC_DESC C*n Lot description supplied by exec length byte = 0
+// Frequency: Exactly one MRR required per data stream.
+// Location: Must be the last record in the data stream.
+// Possible Use: Final Summary Sheet, Datalog, Wafer Map, Trend Report
+type MRR struct {
+	BasicRecordType
+	// Date and time last part tested
+	FINISH_T Timestamp `stdf:"missing=0"`
+	// Lot disposition code space
+	DISP_COD C1 `stdf:"missing=space"`
+	// Lot description supplied by user length byte = 0
+	USR_DESC CN `stdf:"missing=empty"`
+	// Lot description supplied by exec length byte = 0
+	EXC_DESC CN `stdf:"missing=empty"`
+}
+
+func (f MRR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f MRR) ToString() string {
+	return f.String()
+}
+
+func (f MRR) String() string {
+	return recordString(&f)
+}
+
+func (f MRR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *MRR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f MRR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Part Count Record (PCR)
+// Function: Contains the part count totals for one or all test sites. Each data stream must have at
+// least one PCR to show the part count.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (1)
+// REC_SUB U*1 Record sub-type (30)
+// HEAD_NUM U*1 Test head number See note
+// SITE_NUM U*1 Test site number
+// PART_CNT U*4 Number of parts tested
+// RTST_CNT U*4 Number of parts retested 4,294,967,295
+// ABRT_CNT U*4 Number of aborts during testing 4,294,967,295
+// GOOD_CNT U*4 Number of good (passed) parts tested 4,294,967,295
+// FUNC_CNT U*4 Number of functional parts tested 4,294,967,295
+// Notes on Specific Fields:
+// HEAD_NUM If this PCR contains a summary of the part counts for all test sites, this field must be
+// set to 255.
+// Frequency: There must be at least one PCR in the file: either one summary PCR for all test sites
+// (HEAD_NUM = 255), or one PCR for each head/site combination, or both.
+// Location: Anywhere in the data stream after the initial sequence and before the MRR.
+// Possible Use: Final Summary Sheet, Merged Summary Sheet
+type PCR struct {
+	BasicRecordType
+	// Test head number See note
+	HEAD_NUM U1
+	// Test site number
+	SITE_NUM U1
+	// Number of parts tested
+	PART_CNT U4
+	// Number of parts retested 4,294,967,295
+	RTST_CNT U4 `stdf:"missing=4294967295"`
+	// Number of aborts during testing 4,294,967,295
+	ABRT_CNT U4 `stdf:"missing=4294967295"`
+	// Number of good (passed) parts tested 4,294,967,295
+	GOOD_CNT U4 `stdf:"missing=4294967295"`
+	// Number of functional parts tested 4,294,967,295
+	FUNC_CNT U4 `stdf:"missing=4294967295"`
+}
+
+func (f PCR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f PCR) ToString() string {
+	return f.String()
+}
+
+func (f PCR) String() string {
+	return recordString(&f)
+}
+
+func (f PCR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *PCR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f PCR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Hardware Bin Record (HBR)
+// Function: Stores a count of the parts "physically" placed in a particular bin after testing. (In
+// wafer testing, "physical" binning is not an actual transfer of the chip, but rather is
+// represented by a drop of ink or an entry in a wafer map file.) This bin count can be for
+// a single test site (when parallel testing) or a total for all test sites. The STDF
+// specification also supports a Software Bin Record (SBR) for logical binning categories.
+// A part is "physically" placed in a hardware bin after testing. A part can be "logically"
+// associated with a software bin during or after testing.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (1)
+// REC_SUB U*1 Record sub-type (40)
+// HEAD_NUM U*1 Test head number See note
+// SITE_NUM U*1 Test site number
+// HBIN_NUM U*2 Hardware bin number
+// HBIN_CNT U*4 Number of parts in bin
+// HBIN_PF C*1 Pass/fail indication space
+// HBIN_NAM C*n Name of hardware bin length byte = 0
+// Notes on Specific Fields:
+// HEAD_NUM If this HBR contains a summary of the hardware bin counts for all test sites, this field
+// must be set to 255.
+// HBIN_PF This field indicates whether the hardware bin was a passing or failing bin. Valid values
+// for this field are: P = Passing bin, F = Failing bin, space = Unknown
+// Frequency: One per hardware bin for each site, and/or one per hardware bin for bin totals.
+// Location: Anywhere in the data stream after the initial sequence and before the MRR.
+// Possible Use: Final Summary Sheet, Merged Summary Sheet
+type HBR struct {
+	BasicRecordType
+	// Test head number See note
+	HEAD_NUM U1
+	// Test site number
+	SITE_NUM U1
+	// Hardware bin number
+	HBIN_NUM U2
+	// Number of parts in bin
+	HBIN_CNT U4
+	// Pass/fail indication space
+	HBIN_PF C1 `stdf:"missing=space"`
+	// Name of hardware bin length byte = 0
+	HBIN_NAM CN `stdf:"missing=empty"`
+}
+
+func (f HBR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f HBR) ToString() string {
+	return f.String()
+}
+
+func (f HBR) String() string {
+	return recordString(&f)
+}
+
+func (f HBR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *HBR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f HBR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Software Bin Record (SBR)
+// Function: Stores a count of the parts associated with a particular logical bin after testing. This
+// bin count can be for a single test site (when parallel testing) or a total for all test sites.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (1)
+// REC_SUB U*1 Record sub-type (50)
+// HEAD_NUM U*1 Test head number See note
+// SITE_NUM U*1 Test site number
+// SBIN_NUM U*2 Software bin number
+// SBIN_CNT U*4 Number of parts in bin
+// SBIN_PF C*1 Pass/fail indication space
+// SBIN_NAM C*n Name of software bin length byte = 0
+// Notes on Specific Fields:
+// HEAD_NUM If this SBR contains a summary of the software bin counts for all test sites, this field
+// must be set to 255.
+// Frequency: One per software bin for each site, and/or one per software bin for bin totals.
+// Location: Anywhere in the data stream after the initial sequence and before the MRR.
+// Possible Use: Final Summary Sheet, Merged Summary Sheet
+type SBR struct {
+	BasicRecordType
+	// Test head number See note
+	HEAD_NUM U1
+	// Test site number
+	SITE_NUM U1
+	// Software bin number
+	SBIN_NUM U2
+	// Number of parts in bin
+	SBIN_CNT U4
+	// Pass/fail indication space
+	SBIN_PF C1 `stdf:"missing=space"`
+	// Name of software bin length byte = 0
+	SBIN_NAM CN `stdf:"missing=empty"`
+}
+
+func (f SBR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f SBR) ToString() string {
+	return f.String()
+}
+
+func (f SBR) String() string {
+	return recordString(&f)
+}
+
+func (f SBR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *SBR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f SBR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Pin Map Record (PMR)
+// Function: Provides indexing of tester channel names, and maps them to physical and logical pin
+// names. Each PMR defines the information for a single channel/pin combination.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (1)
+// REC_SUB U*1 Record sub-type (60)
+// PMR_INDX U*2 Unique index associated with pin
+// CHAN_TYP U*2 Channel type 0
+// CHAN_NAM C*n Channel name length byte = 0
+// PHY_NAM C*n Physical name of pin length byte = 0
+// LOG_NAM C*n Logical name of pin length byte = 0
+// HEAD_NUM U*1 Head number associated with channel 1
+// SITE_NUM U*1 Site number associated with channel 1
+// Frequency: One per channel/pin combination used in the test program.
+// Location: After the initial sequence and before the first PGR, PLR, FTR, or MPR that uses this
+// record's PMR_INDX value.
+// Possible Use: Functional Datalog, Functional Histogram
+type PMR struct {
+	BasicRecordType
+	// Unique index associated with pin
+	PMR_INDX U2
+	// Channel type 0
+	CHAN_TYP U2 `stdf:"missing=0"`
+	// Channel name length byte = 0
+	CHAN_NAM CN `stdf:"missing=empty"`
+	// Physical name of pin length byte = 0
+	PHY_NAM CN `stdf:"missing=empty"`
+	// Logical name of pin length byte = 0
+	LOG_NAM CN `stdf:"missing=empty"`
+	// Head number associated with channel 1
+	HEAD_NUM U1 `stdf:"missing=1"`
+	// Site number associated with channel 1
+	SITE_NUM U1 `stdf:"missing=1"`
+}
+
+func (f PMR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f PMR) ToString() string {
+	return f.String()
+}
+
+func (f PMR) String() string {
+	return recordString(&f)
+}
+
+func (f PMR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *PMR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f PMR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Pin Group Record (PGR)
+// Function: Associates a name with a group of pins.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (1)
+// REC_SUB U*1 Record sub-type (62)
+// GRP_INDX U*2 Unique index associated with pin group
+// GRP_NAM C*n Name of pin group length byte = 0
+// INDX_CNT U*2 Count (k) of PMR indexes
+// PMR_INDX kxU*2 Array of indexes for pins in the group INDX_CNT = 0
+// Notes on Specific Fields:
+// GRP_INDX The unique index assigned to the pin group. It must be greater than 32,767.
+// Frequency: One per pin group defined in the test program.
+// Location: After all the PMRs whose PMR index values are listed in the PMR_INDX array of this
+// record; and before the first PLR that uses this record's GRP_INDX value.
+// Possible Use: Functional Datalog
+type PGR struct {
+	BasicRecordType
+	// Unique index associated with pin group
+	GRP_INDX U2
+	// Name of pin group length byte = 0
+	GRP_NAM CN `stdf:"missing=empty"`
+	// Count (k) of PMR indexes
+	INDX_CNT U2
+	// Array of indexes for pins in the group INDX_CNT = 0
+	PMR_INDX KXU2 `stdf:"count=INDX_CNT,missing=empty"`
+}
+
+func (f PGR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f PGR) ToString() string {
+	return f.String()
+}
+
+func (f PGR) String() string {
+	return recordString(&f)
+}
+
+func (f PGR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *PGR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f PGR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Pin List Record (PLR)
+// Function: Defines the current display radix and operating mode for a pin or pin group.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (1)
+// REC_SUB U*1 Record sub-type (63)
+// GRP_CNT U*2 Count (k) of pins or pin groups
+// GRP_INDX kxU*2 Array of pin or pin group indexes
+// GRP_MODE kxU*2 Operating mode of pin group 0
+// GRP_RADX kxU*1 Display radix of pin group 0
+// PGM_CHAR kxC*n Program state encoding characters length byte = 0
+// RTN_CHAR kxC*n Return state encoding characters length byte = 0
+// PGM_CHAL kxC*n Program state encoding characters length byte = 0
+// RTN_CHAL kxC*n Return state encoding characters length byte = 0
+// Notes on Specific Fields:
+// GRP_CNT GRP_CNT defines the number of pins or pin groups whose radix and mode are being
+// defined. Therefore, it defines the size of each of the arrays that follow in the record.
+// Frequency: One or more whenever the usage of a pin or pin group changes in the test program.
+// Location: After all the PMRs and PGRs whose PMR index values and pin group index values are
+// listed in the GRP_INDX array of this record; and before the first FTR that references pins
+// or pin groups whose modes are defined in this record.
+// Possible Use: Functional Datalog
+type PLR struct {
+	BasicRecordType
+	// Count (k) of pins or pin groups
+	GRP_CNT U2
+	// Array of pin or pin group indexes
+	GRP_INDX KXU2 `stdf:"count=GRP_CNT"`
+	// Operating mode of pin group 0
+	GRP_MODE KXU2 `stdf:"count=GRP_CNT,missing=empty"`
+	// Display radix of pin group 0
+	GRP_RADX KXU1 `stdf:"count=GRP_CNT,missing=empty"`
+	// Program state encoding characters length byte = 0
+	PGM_CHAR KXCN `stdf:"count=GRP_CNT,missing=empty"`
+	// Return state encoding characters length byte = 0
+	RTN_CHAR KXCN `stdf:"count=GRP_CNT,missing=empty"`
+	// Program state encoding characters length byte = 0
+	PGM_CHAL KXCN `stdf:"count=GRP_CNT,missing=empty"`
+	// Return state encoding characters length byte = 0
+	RTN_CHAL KXCN `stdf:"count=GRP_CNT,missing=empty"`
+}
+
+func (f PLR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f PLR) ToString() string {
+	return f.String()
+}
+
+func (f PLR) String() string {
+	return recordString(&f)
+}
+
+func (f PLR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *PLR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f PLR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Retest Data Record (RDR)
+// Function: Signals that the data in this STDF file is for retested parts. The data in this record,
+// combined with information in the MIR, tells data filtering programs what data to replace
+// when processing retest data.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (1)
+// REC_SUB U*1 Record sub-type (70)
+// NUM_BINS U*2 Number (k) of bins being retested
+// RTST_BIN kxU*2 Array of retest bin numbers NUM_BINS = 0
+// Notes on Specific Fields:
+// NUM_BINS, RTST_BIN NUM_BINS is the number of hardware bins being retested, RTST_BIN is the array of
+// their bin numbers. If all bins are being retested, NUM_BINS must be set to 0.
+// Frequency: Optional. One per data stream.
+// Location: If this record is used, it must appear immediately after the Master Information Record
+// (MIR).
+// Possible Use: Tells data filtering programs how to handle retest data.
+type RDR struct {
+	BasicRecordType
+	// Number (k) of bins being retested
+	NUM_BINS U2
+	// Array of retest bin numbers NUM_BINS = 0
+	RTST_BIN KXU2 `stdf:"count=NUM_BINS,missing=empty"`
+}
+
+func (f RDR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f RDR) ToString() string {
+	return f.String()
+}
+
+func (f RDR) String() string {
+	return recordString(&f)
+}
+
+func (f RDR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *RDR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f RDR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Wafer Information Record (WIR)
+// Function: Acts mainly as a marker to indicate where testing of a particular wafer begins for each
+// wafer tested by the job plan. The WIR and the Wafer Results Record (WRR) bracket all
+// the stored information pertaining to one tested wafer.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (2)
+// REC_SUB U*1 Record sub-type (10)
+// HEAD_NUM U*1 Test head number
+// SITE_GRP U*1 Site group number 255
+// START_T U*4 Date and time first part tested
+// WAFER_ID C*n Wafer ID length byte = 0
+// Frequency: Obligatory for each wafer tested. One per wafer tested.
+// Location: Anywhere in the data stream after the initial sequence and before the MRR.
+// Possible Use: Wafer Summary Sheet, Wafer Map
+type WIR struct {
+	BasicRecordType
+	// Test head number
+	HEAD_NUM U1
+	// Site group number 255
+	SITE_GRP U1 `stdf:"missing=255"`
+	// Date and time first part tested
+	START_T Timestamp `stdf:"missing=0"`
+	// Wafer ID length byte = 0
+	WAFER_ID CN `stdf:"missing=empty"`
+}
+
+func (f WIR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f WIR) ToString() string {
+	return f.String()
+}
+
+func (f WIR) String() string {
+	return recordString(&f)
+}
+
+func (f WIR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *WIR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f WIR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Wafer Results Record (WRR)
+// Function: Contains the result information relating to each wafer tested by the job plan. The WRR
+// and the Wafer Information Record (WIR) bracket all the stored information pertaining
+// to one tested wafer.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (2)
+// REC_SUB U*1 Record sub-type (20)
+// HEAD_NUM U*1 Test head number
+// SITE_GRP U*1 Site group number 255
+// FINISH_T U*4 Date and time last part tested
+// PART_CNT U*4 Number of parts tested
+// RTST_CNT U*4 Number of parts retested 4,294,967,295
+// ABRT_CNT U*4 Number of aborts during testing 4,294,967,295
+// GOOD_CNT U*4 Number of good (passed) parts tested 4,294,967,295
+// FUNC_CNT U*4 Number of functional parts tested 4,294,967,295
+// WAFER_ID C*n Wafer ID length byte = 0
+// FABWF_ID C*n Fab wafer ID length byte = 0
+// FRAME_ID C*n Wafer frame ID length byte = 0
+// MASK_ID C*n Wafer mask ID length byte = 0
+// USR_DESC C*n Wafer description supplied by user length byte = 0
+// EXC_DESC C*n Wafer description supplied by exec length byte = 0
+// Frequency: Obligatory for each wafer tested. One per wafer tested.
+// Location: Anywhere in the data stream after the corresponding WIR.
+// Possible Use: Wafer Summary Sheet, Wafer Map
+type WRR struct {
+	BasicRecordType
+	// Test head number
+	HEAD_NUM U1
+	// Site group number 255
+	SITE_GRP U1 `stdf:"missing=255"`
+	// Date and time last part tested
+	FINISH_T Timestamp `stdf:"missing=0"`
+	// Number of parts tested
+	PART_CNT U4
+	// Number of parts retested 4,294,967,295
+	RTST_CNT U4 `stdf:"missing=4294967295"`
+	// Number of aborts during testing 4,294,967,295
+	ABRT_CNT U4 `stdf:"missing=4294967295"`
+	// Number of good (passed) parts tested 4,294,967,295
+	GOOD_CNT U4 `stdf:"missing=4294967295"`
+	// Number of functional parts tested 4,294,967,295
+	FUNC_CNT U4 `stdf:"missing=4294967295"`
+	// Wafer ID length byte = 0
+	WAFER_ID CN `stdf:"missing=empty"`
+	// Fab wafer ID length byte = 0
+	FABWF_ID CN `stdf:"missing=empty"`
+	// Wafer frame ID length byte = 0
+	FRAME_ID CN `stdf:"missing=empty"`
+	// Wafer mask ID length byte = 0
+	MASK_ID CN `stdf:"missing=empty"`
+	// Wafer description supplied by user length byte = 0
+	USR_DESC CN `stdf:"missing=empty"`
+	// Wafer description supplied by exec length byte = 0
+	EXC_DESC CN `stdf:"missing=empty"`
+}
+
+func (f WRR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f WRR) ToString() string {
+	return f.String()
+}
+
+func (f WRR) String() string {
+	return recordString(&f)
+}
+
+func (f WRR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *WRR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f WRR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Wafer Configuration Record (WCR)
+// Function: Contains the configuration information for the wafers tested by the job plan. The
+// WCR provides the dimensions and orientation information for all wafers and dice
+// in the lot. This record is used only when testing at wafer probe time.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (2)
+// REC_SUB U*1 Record sub-type (30)
+// WAFR_SIZ R*4 Diameter of wafer in WF_UNITS 0
+// DIE_HT R*4 Height of die in WF_UNITS 0
+// DIE_WID R*4 Width of die in WF_UNITS 0
+// WF_UNITS U*1 Units for wafer and die dimensions 0
+// WF_FLAT C*1 Orientation of wafer flat space
+// CENTER_X I*2 X coordinate of center die on wafer -32768
+// CENTER_Y I*2 Y coordinate of center die on wafer -32768
+// POS_X C*1 Positive X direction of wafer space
+// POS_Y C*1 Positive Y direction of wafer space
+// Notes on Specific Fields:
+// WF_UNITS Has these valid values: 0 = Unknown units, 1 = Units are in inches,
+// 2 = Units are in centimeters, 3 = Units are in millimeters, 4 = Units are in mils
+// WF_FLAT Has these valid values: U = Up, D = Down, L = Left, R = Right, space = Unknown
+// CENTER_X, CENTER_Y Use the value -32768 to indicate that the field is invalid.
+// POS_X Has these valid values: L = Left, R = Right, space = Unknown
+// POS_Y Has these valid values: U = Up, D = Down, space = Unknown
+// Frequency: One per STDF file (used only if wafer testing).
+// Location: Anywhere in the data stream after the initial sequence, and before the MRR.
+// Possible Use: Wafer Map
+type WCR struct {
+	BasicRecordType
+	// Diameter of wafer in WF_UNITS 0
+	WAFR_SIZ R4 `stdf:"missing=0"`
+	// Height of die in WF_UNITS 0
+	DIE_HT R4 `stdf:"missing=0"`
+	// Width of die in WF_UNITS 0
+	DIE_WID R4 `stdf:"missing=0"`
+	// Units for wafer and die dimensions 0
+	WF_UNITS U1 `stdf:"missing=0"`
+	// Orientation of wafer flat space
+	WF_FLAT C1 `stdf:"missing=space"`
+	// X coordinate of center die on wafer -32768
+	CENTER_X I2 `stdf:"missing=-32768"`
+	// Y coordinate of center die on wafer -32768
+	CENTER_Y I2 `stdf:"missing=-32768"`
+	// Positive X direction of wafer space
+	POS_X C1 `stdf:"missing=space"`
+	// Positive Y direction of wafer space
+	POS_Y C1 `stdf:"missing=space"`
+}
+
+func (f WCR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f WCR) ToString() string {
+	return f.String()
+}
+
+func (f WCR) String() string {
+	return recordString(&f)
+}
+
+func (f WCR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *WCR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f WCR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Part Information Record (PIR)
+// Function: Acts as a marker to indicate where testing of a particular part begins for each part
+// tested by the test program. The PIR and the Part Results Record (PRR) bracket all the
+// stored information pertaining to one tested part.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (5)
+// REC_SUB U*1 Record sub-type (10)
+// HEAD_NUM U*1 Test head number
+// SITE_NUM U*1 Test site number
+// Frequency: Obligatory for each part tested. One per part tested.
+// Location: Anywhere in the data stream after the initial sequence, and before the corresponding
+// PRR. Sent before testing each part.
+// Possible Use: Datalog
+type PIR struct {
+	BasicRecordType
+	// Test head number
+	HEAD_NUM U1
+	// Test site number
+	SITE_NUM U1
+}
+
+func (f PIR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f PIR) ToString() string {
+	return f.String()
+}
+
+func (f PIR) String() string {
+	return recordString(&f)
+}
+
+func (f PIR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *PIR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f PIR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Part Results Record (PRR)
+// Function: Contains the result information relating to each part tested by the test program. The
+// PRR and the Part Information Record (PIR) bracket all the stored information
+// pertaining to one tested part.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (5)
+// REC_SUB U*1 Record sub-type (20)
+// HEAD_NUM U*1 Test head number
+// SITE_NUM U*1 Test site number
+// PART_FLG B*1 Part information flag
+// NUM_TEST U*2 Number of tests executed
+// HARD_BIN U*2 Hardware bin number
+// SOFT_BIN U*2 Software bin number 65535
+// X_COORD I*2 (Wafer) X coordinate -32768
+// Y_COORD I*2 (Wafer) Y coordinate -32768
+// TEST_T U*4 Elapsed test time in milliseconds 0
+// PART_ID C*n Part identification length byte = 0
+// PART_TXT C*n Part description text length byte = 0
+// PART_FIX B*n Part repair information length byte = 0
+// Notes on Specific Fields:
+// PART_FLG Contains the following fields:
+// bit 0: 0 = This is a new part. 1 = retest of a part with the same PART_ID.
+// bit 1: 0 = This is a new part. 1 = retest of a part with the same X_COORD/Y_COORD.
+// bit 2: 0 = Part testing completed normally. 1 = Abnormal end of testing.
+// bit 3: 0 = Part passed. 1 = Part failed.
+// bit 4: 0 = Pass/fail flag (bit 3) is valid. 1 = Device completed testing with no pass/fail indication.
+// bits 5 - 7: Reserved for future use - must be 0
+// HARD_BIN Has legal values in the range 0 to 32767.
+// SOFT_BIN Has legal values in the range 0 to 32767. A value of 65535 means the software bin
+// number is missing.
+// Frequency: One per part tested.
+// Location: Anywhere in the data stream after the corresponding PIR and before the MRR. Sent after
+// completion of testing each part.
+// Possible Use: Datalog, Wafer Map, Shmoo Plot, Repair Data
+type PRR struct {
+	BasicRecordType
+	// Test head number
+	HEAD_NUM U1
+	// Test site number
+	SITE_NUM U1
+	// Part information flag
+	PART_FLG PartFlag
+	// Number of tests executed
+	NUM_TEST U2
+	// Hardware bin number
+	HARD_BIN U2
+	// Software bin number 65535
+	SOFT_BIN U2 `stdf:"missing=65535"`
+	// (Wafer) X coordinate -32768
+	X_COORD I2 `stdf:"missing=-32768"`
+	// (Wafer) Y coordinate -32768
+	Y_COORD I2 `stdf:"missing=-32768"`
+	// Elapsed test time in milliseconds 0
+	TEST_T U4 `stdf:"missing=0"`
+	// Part identification length byte = 0
+	PART_ID CN `stdf:"missing=empty"`
+	// Part description text length byte = 0
+	PART_TXT CN `stdf:"missing=empty"`
+	// Part repair information length byte = 0
+	PART_FIX BN `stdf:"missing=empty"`
+}
+
+func (f PRR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f PRR) ToString() string {
+	return f.String()
+}
+
+func (f PRR) String() string {
+	return recordString(&f)
+}
+
+func (f PRR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *PRR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f PRR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// PartFlag is the PART_FLG of a PRR.
+type PartFlag B1
+
+// RetestByID reports bit 0: the part is a retest of a part with the same
+// PART_ID.
+func (f PartFlag) RetestByID() bool { return f&0x01 != 0 }
+
+// RetestByCoord reports bit 1: the part is a retest of a part with the same
+// X_COORD/Y_COORD.
+func (f PartFlag) RetestByCoord() bool { return f&0x02 != 0 }
+
+// Retest reports whether the part supersedes an earlier one, by PART_ID or
+// by coordinates.
+func (f PartFlag) Retest() bool { return f&0x03 != 0 }
+
+// AbnormalEnd reports bit 2: part testing ended abnormally.
+func (f PartFlag) AbnormalEnd() bool { return f&0x04 != 0 }
+
+// PassFailValid reports bit 4 clear: the part completed testing with a
+// pass/fail indication.
+func (f PartFlag) PassFailValid() bool { return f&0x10 == 0 }
+
+// Passed reports a valid pass/fail flag (bit 3) that says the part passed.
+func (f PartFlag) Passed() bool { return f&0x18 == 0 }
+
+// Failed reports a valid pass/fail flag (bit 3) that says the part failed.
+func (f PartFlag) Failed() bool { return f&0x18 == 0x08 }
+
+// Test Synopsis Record (TSR)
+// Function: Contains the test execution and failure counts for one parametric or functional test in
+// the test program. Also contains static information, such as test name. The TSR is
+// related to the Functional Test Record (FTR), the Parametric Test Record (PTR), and the
+// Multiple Parametric Test Record (MPR) by test number, head number, and site number.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (10)
+// REC_SUB U*1 Record sub-type (30)
+// HEAD_NUM U*1 Test head number See note
+// SITE_NUM U*1 Test site number
+// TEST_TYP C*1 Test type space
+// TEST_NUM U*4 Test number
+// EXEC_CNT U*4 Number of test executions 4,294,967,295
+// FAIL_CNT U*4 Number of test failures 4,294,967,295
+// ALRM_CNT U*4 Number of alarmed tests 4,294,967,295
+// TEST_NAM C*n Test name length byte = 0
+// SEQ_NAME C*n Sequencer (program segment/flow) name length byte = 0
+// TEST_LBL C*n Test label or text length byte = 0
+// OPT_FLAG B*1 Optional data flag See note
+// TEST_TIM R*4 Average test execution time in seconds OPT_FLAG bit 2 = 1
+// TEST_MIN R*4 Lowest test result value OPT_FLAG bit 0 = 1
+// TEST_MAX R*4 Highest test result value OPT_FLAG bit 1 = 1
+// TST_SUMS R*4 Sum of test result values OPT_FLAG bit 4 = 1
+// TST_SQRS R*4 Sum of squares of test result values OPT_FLAG bit 5 = 1
+// Notes on Specific Fields:
+// HEAD_NUM If this TSR contains a summary of the test counts for all test sites, this field must be
+// set to 255.
+// TEST_TYP Indicates what type of test this summary data is for. Valid values are:
+// P = Parametric test, F = Functional test, M = Multiple-result parametric test,
+// space = Unknown
+// OPT_FLAG Contains the following fields:
+// bit 0 set = TEST_MIN value is invalid
+// bit 1 set = TEST_MAX value is invalid
+// bit 2 set = TEST_TIM value is invalid
+// bit 3 is reserved for future use and must be 1
+// bit 4 set = TST_SUMS value is invalid
+// bit 5 set = TST_SQRS value is invalid
+// bits 6 - 7 are reserved for future use and must be 1
+// Frequency: One for each test executed in the test program.
+// Location: Anywhere in the data stream after the initial sequence and before the MRR.
+// Possible Use: Test Results Synopsis Report, Trend Report
+type TSR struct {
+	BasicRecordType
+	// Test head number See note
+	HEAD_NUM U1
+	// Test site number
+	SITE_NUM U1
+	// Test type space
+	TEST_TYP C1 `stdf:"missing=space"`
+	// Test number
+	TEST_NUM U4
+	// Number of test executions 4,294,967,295
+	EXEC_CNT U4 `stdf:"missing=4294967295"`
+	// Number of test failures 4,294,967,295
+	FAIL_CNT U4 `stdf:"missing=4294967295"`
+	// Number of alarmed tests 4,294,967,295
+	ALRM_CNT U4 `stdf:"missing=4294967295"`
+	// Test name length byte = 0
+	TEST_NAM CN `stdf:"missing=empty"`
+	// Sequencer (program segment/flow) name length byte = 0
+	SEQ_NAME CN `stdf:"missing=empty"`
+	// Test label or text length byte = 0
+	TEST_LBL CN `stdf:"missing=empty"`
+	// Optional data flag See note
+	OPT_FLAG B1
+	// Average test execution time in seconds OPT_FLAG bit 2 = 1
+	TEST_TIM R4 `stdf:"flag=OPT_FLAG&0x04"`
+	// Lowest test result value OPT_FLAG bit 0 = 1
+	TEST_MIN R4 `stdf:"flag=OPT_FLAG&0x01"`
+	// Highest test result value OPT_FLAG bit 1 = 1
+	TEST_MAX R4 `stdf:"flag=OPT_FLAG&0x02"`
+	// Sum of test result values OPT_FLAG bit 4 = 1
+	TST_SUMS R4 `stdf:"flag=OPT_FLAG&0x10"`
+	// Sum of squares of test result values OPT_FLAG bit 5 = 1
+	TST_SQRS R4 `stdf:"flag=OPT_FLAG&0x20"`
+}
+
+func (f TSR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f TSR) ToString() string {
+	return f.String()
+}
+
+func (f TSR) String() string {
+	return recordString(&f)
+}
+
+func (f TSR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *TSR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f TSR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Parametric Test Record (PTR)
+// Function: Contains the results of a single execution of a parametric test in the test program. The
+// first occurrence of this record also establishes the default values for all semi-static
+// information about the test, such as limits, units, and scaling. The PTR is related to the
+// Test Synopsis Record (TSR) by test number, head number, and site number.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (15)
+// REC_SUB U*1 Record sub-type (10)
+// TEST_NUM U*4 Test number
+// HEAD_NUM U*1 Test head number
+// SITE_NUM U*1 Test site number
+// TEST_FLG B*1 Test flags (fail, alarm, etc.)
+// PARM_FLG B*1 Parametric test flags (drift, etc.)
+// RESULT R*4 Test result TEST_FLG bit 1 = 1
+// TEST_TXT C*n Test description text or label length byte = 0
+// ALARM_ID C*n Name of alarm length byte = 0
+// OPT_FLAG B*1 Optional data flag (See note) See note
+// RES_SCAL I*1 Test results scaling exponent OPT_FLAG bit 0 = 1
+// LLM_SCAL I*1 Low limit scaling exponent OPT_FLAG bit 4 or 6 = 1
+// HLM_SCAL I*1 High limit scaling exponent OPT_FLAG bit 5 or 7 = 1
+// LO_LIMIT R*4 Low test limit value OPT_FLAG bit 4 or 6 = 1
+// HI_LIMIT R*4 High test limit value OPT_FLAG bit 5 or 7 = 1
+// UNITS C*n Test units length byte = 0
+// C_RESFMT C*n ANSI C result format string length byte = 0
+// C_LLMFMT C*n ANSI C low limit format string length byte = 0
+// C_HLMFMT C*n ANSI C high limit format string length byte = 0
+// LO_SPEC R*4 Low specification limit value OPT_FLAG bit 2 = 1
+// HI_SPEC R*4 High specification limit value OPT_FLAG bit 3 = 1
+// Notes on Specific Fields:
+// Default Data All data following the OPT_FLAG field has a special function in the STDF file. The first
+// PTR for each test will have these fields filled in. These values will be the default for
+// each subsequent PTR with the same test number: if a subsequent PTR has a value for
+// one of these fields, it will be used instead of the default, for that one record only; if the
+// field is blank, the default will be used.
+// OPT_FLAG Contains the following fields:
+// bit 0 set = RES_SCAL value is invalid
+// bit 1 reserved for future used and must be set to 1
+// bit 2 set = No low specification limit
+// bit 3 set = No high specification limit
+// bit 4 set = LO_LIMIT and LLM_SCAL are invalid
+// bit 5 set = HI_LIMIT and HLM_SCAL are invalid
+// bit 6 set = No Low Limit for this test
+// bit 7 set = No High Limit for this test
+// Frequency: One per parametric test execution.
+// Location: Under normal circumstances, the PTR can appear anywhere in the data stream after
+// the corresponding Part Information Record (PIR) and before the corresponding Part
+// Result Record (PRR).
+// Possible Use: Datalog, Histogram, Wafer Map
+type PTR struct {
+	BasicRecordType
+	// Test number
+	TEST_NUM U4
+	// Test head number
+	HEAD_NUM U1
+	// Test site number
+	SITE_NUM U1
+	// Test flags (fail, alarm, etc.)
+	TEST_FLG TestFlag
+	// Parametric test flags (drift, etc.)
+	PARM_FLG ParmFlag
+	// Test result TEST_FLG bit 1 = 1
+	RESULT R4 `stdf:"flag=TEST_FLG&0x02"`
+	// Test description text or label length byte = 0
+	TEST_TXT CN `stdf:"missing=empty"`
+	// Name of alarm length byte = 0
+	ALARM_ID CN `stdf:"missing=empty"`
+	// Optional data flag (See note) See note
+	OPT_FLAG OptFlag
+	// Test results scaling exponent OPT_FLAG bit 0 = 1
+	RES_SCAL I1 `stdf:"flag=OPT_FLAG&0x01"`
+	// Low limit scaling exponent OPT_FLAG bit 4 or 6 = 1
+	LLM_SCAL I1 `stdf:"flag=OPT_FLAG&0x50"`
+	// High limit scaling exponent OPT_FLAG bit 5 or 7 = 1
+	HLM_SCAL I1 `stdf:"flag=OPT_FLAG&0xa0"`
+	// Low test limit value OPT_FLAG bit 4 or 6 = 1
+	LO_LIMIT R4 `stdf:"flag=OPT_FLAG&0x50"`
+	// High test limit value OPT_FLAG bit 5 or 7 = 1
+	HI_LIMIT R4 `stdf:"flag=OPT_FLAG&0xa0"`
+	// Test units length byte = 0
+	UNITS CN `stdf:"missing=empty"`
+	// ANSI C result format string length byte = 0
+	C_RESFMT CN `stdf:"missing=empty"`
+	// ANSI C low limit format string length byte = 0
+	C_LLMFMT CN `stdf:"missing=empty"`
+	// ANSI C high limit format string length byte = 0
+	C_HLMFMT CN `stdf:"missing=empty"`
+	// Low specification limit value OPT_FLAG bit 2 = 1
+	LO_SPEC R4 `stdf:"flag=OPT_FLAG&0x04"`
+	// High specification limit value OPT_FLAG bit 3 = 1
+	HI_SPEC R4 `stdf:"flag=OPT_FLAG&0x08"`
+}
+
+func (f PTR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f PTR) ToString() string {
+	return f.String()
+}
+
+func (f PTR) String() string {
+	return recordString(&f)
+}
+
+func (f PTR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *PTR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f PTR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// TestFlag is the TEST_FLG of a PTR, MPR or FTR. Bit 1 is reserved in an
+// FTR, so ResultValid means nothing there.
+type TestFlag B1
+
+// Alarm reports bit 0: an alarm was detected during testing.
+func (f TestFlag) Alarm() bool { return f&0x01 != 0 }
+
+// ResultValid reports bit 1 clear: the RESULT value is valid.
+func (f TestFlag) ResultValid() bool { return f&0x02 == 0 }
+
+// Unreliable reports bit 2: the test result is unreliable.
+func (f TestFlag) Unreliable() bool { return f&0x04 != 0 }
+
+// Timeout reports bit 3: a timeout occurred.
+func (f TestFlag) Timeout() bool { return f&0x08 != 0 }
+
+// NotExecuted reports bit 4: the test was not executed.
+func (f TestFlag) NotExecuted() bool { return f&0x10 != 0 }
+
+// Aborted reports bit 5: the test was aborted.
+func (f TestFlag) Aborted() bool { return f&0x20 != 0 }
+
+// PassFailValid reports bit 6 clear: the test completed with a pass/fail
+// indication.
+func (f TestFlag) PassFailValid() bool { return f&0x40 == 0 }
+
+// Passed reports a valid pass/fail flag (bit 7) that says the test passed.
+func (f TestFlag) Passed() bool { return f&0xc0 == 0 }
+
+// Failed reports a valid pass/fail flag (bit 7) that says the test failed.
+func (f TestFlag) Failed() bool { return f&0xc0 == 0x80 }
+
+// ParmFlag is the PARM_FLG of a PTR or MPR.
+type ParmFlag B1
+
+// ScaleError reports bit 0: scale error.
+func (f ParmFlag) ScaleError() bool { return f&0x01 != 0 }
+
+// DriftError reports bit 1: drift error (unstable measurement).
+func (f ParmFlag) DriftError() bool { return f&0x02 != 0 }
+
+// Oscillation reports bit 2: oscillation detected.
+func (f ParmFlag) Oscillation() bool { return f&0x04 != 0 }
+
+// AboveHighLimit reports bit 3: the measured value is higher than the
+// high test limit.
+func (f ParmFlag) AboveHighLimit() bool { return f&0x08 != 0 }
+
+// BelowLowLimit reports bit 4: the measured value is lower than the low
+// test limit.
+func (f ParmFlag) BelowLowLimit() bool { return f&0x10 != 0 }
+
+// AlternateLimits reports bit 5: the test passed alternate limits.
+func (f ParmFlag) AlternateLimits() bool { return f&0x20 != 0 }
+
+// LowLimitInclusive reports bit 6: a result equal to the low limit passes.
+func (f ParmFlag) LowLimitInclusive() bool { return f&0x40 != 0 }
+
+// HighLimitInclusive reports bit 7: a result equal to the high limit passes.
+func (f ParmFlag) HighLimitInclusive() bool { return f&0x80 != 0 }
+
+// OptFlag is the OPT_FLAG of a PTR or MPR.
+type OptFlag B1
+
+// ResScaleInvalid reports bit 0: RES_SCAL is invalid.
+func (f OptFlag) ResScaleInvalid() bool { return f&0x01 != 0 }
+
+// InputInvalid reports bit 1 of an MPR: START_IN and INCR_IN are invalid.
+// In a PTR the bit is reserved and set.
+func (f OptFlag) InputInvalid() bool { return f&0x02 != 0 }
+
+// NoLowSpec reports bit 2: there is no low specification limit.
+func (f OptFlag) NoLowSpec() bool { return f&0x04 != 0 }
+
+// NoHighSpec reports bit 3: there is no high specification limit.
+func (f OptFlag) NoHighSpec() bool { return f&0x08 != 0 }
+
+// LowLimitInvalid reports bit 4: LO_LIMIT and LLM_SCAL are invalid.
+func (f OptFlag) LowLimitInvalid() bool { return f&0x10 != 0 }
+
+// HighLimitInvalid reports bit 5: HI_LIMIT and HLM_SCAL are invalid.
+func (f OptFlag) HighLimitInvalid() bool { return f&0x20 != 0 }
+
+// NoLowLimit reports bit 6: the test has no low limit.
+func (f OptFlag) NoLowLimit() bool { return f&0x40 != 0 }
+
+// NoHighLimit reports bit 7: the test has no high limit.
+func (f OptFlag) NoHighLimit() bool { return f&0x80 != 0 }
+
+// HasLowLimit reports whether LO_LIMIT holds a usable low limit.
+func (f OptFlag) HasLowLimit() bool { return f&0x50 == 0 }
+
+// HasHighLimit reports whether HI_LIMIT holds a usable high limit.
+func (f OptFlag) HasHighLimit() bool { return f&0xa0 == 0 }
+
+// Multiple-Result Parametric Record (MPR)
+// Function: Contains the results of a single execution of a parametric test in the test program
+// where that test returns multiple values. The first occurrence of this record also
+// establishes the default values for all semi-static information about the test, such as
+// limits, units, and scaling. The MPR is related to the Test Synopsis Record (TSR) by test
+// number, head number, and site number.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (15)
+// REC_SUB U*1 Record sub-type (15)
+// TEST_NUM U*4 Test number
+// HEAD_NUM U*1 Test head number
+// SITE_NUM U*1 Test site number
+// TEST_FLG B*1 Test flags (fail, alarm, etc.)
+// PARM_FLG B*1 Parametric test flags (drift, etc.)
+// RTN_ICNT U*2 Count (j) of PMR indexes See note
+// RSLT_CNT U*2 Count (k) of returned results See note
+// RTN_STAT jxN*1 Array of j returned states RTN_ICNT = 0
+// RTN_RSLT kxR*4 Array of k returned results RSLT_CNT = 0
+// TEST_TXT C*n Descriptive text or label length byte = 0
+// ALARM_ID C*n Name of alarm length byte = 0
+// OPT_FLAG B*1 Optional data flag See note
+// RES_SCAL I*1 Test result scaling exponent OPT_FLAG bit 0 = 1
+// LLM_SCAL I*1 Test low limit scaling exponent OPT_FLAG bit 4 or 6 = 1
+// HLM_SCAL I*1 Test high limit scaling exponent OPT_FLAG bit 5 or 7 = 1
+// LO_LIMIT R*4 Test low limit value OPT_FLAG bit 4 or 6 = 1
+// HI_LIMIT R*4 Test high limit value OPT_FLAG bit 5 or 7 = 1
+// START_IN R*4 Starting input value (condition) OPT_FLAG bit 1 = 1
+// INCR_IN R*4 Increment of input condition OPT_FLAG bit 1 = 1
+// RTN_INDX jxU*2 Array of j PMR indexes RTN_ICNT = 0
+// UNITS C*n Units of returned results length byte = 0
+// UNITS_IN C*n Input condition units length byte = 0
+// C_RESFMT C*n ANSI C result format string length byte = 0
+// C_LLMFMT C*n ANSI C low limit format string length byte = 0
+// C_HLMFMT C*n ANSI C high limit format string length byte = 0
+// LO_SPEC R*4 Low specification limit value OPT_FLAG bit 2 = 1
+// HI_SPEC R*4 High specification limit value OPT_FLAG bit 3 = 1
+// Notes on Specific Fields:
+// RTN_ICNT, RSLT_CNT RTN_ICNT is the count of the PMR indexes and returned states, RSLT_CNT is the
+// count of the returned results. The two counts may differ.
+// Frequency: One per multiple-result parametric test execution.
+// Location: Anywhere in the data stream after the corresponding Part Information Record (PIR)
+// and before the corresponding Part Result Record (PRR).
+// Possible Use: Datalog, Shmoo Plot
+type MPR struct {
+	BasicRecordType
+	// Test number
+	TEST_NUM U4
+	// Test head number
+	HEAD_NUM U1
+	// Test site number
+	SITE_NUM U1
+	// Test flags (fail, alarm, etc.)
+	TEST_FLG TestFlag
+	// Parametric test flags (drift, etc.)
+	PARM_FLG ParmFlag
+	// Count (j) of PMR indexes See note
+	RTN_ICNT U2
+	// Count (k) of returned results See note
+	RSLT_CNT U2
+	// Array of j returned states RTN_ICNT = 0
+	RTN_STAT KXN1 `stdf:"count=RTN_ICNT,missing=empty"`
+	// Array of k returned results RSLT_CNT = 0
+	RTN_RSLT KXR4 `stdf:"count=RSLT_CNT,missing=empty"`
+	// Descriptive text or label length byte = 0
+	TEST_TXT CN `stdf:"missing=empty"`
+	// Name of alarm length byte = 0
+	ALARM_ID CN `stdf:"missing=empty"`
+	// Optional data flag See note
+	OPT_FLAG OptFlag
+	// Test result scaling exponent OPT_FLAG bit 0 = 1
+	RES_SCAL I1 `stdf:"flag=OPT_FLAG&0x01"`
+	// Test low limit scaling exponent OPT_FLAG bit 4 or 6 = 1
+	LLM_SCAL I1 `stdf:"flag=OPT_FLAG&0x50"`
+	// Test high limit scaling exponent OPT_FLAG bit 5 or 7 = 1
+	HLM_SCAL I1 `stdf:"flag=OPT_FLAG&0xa0"`
+	// Test low limit value OPT_FLAG bit 4 or 6 = 1
+	LO_LIMIT R4 `stdf:"flag=OPT_FLAG&0x50"`
+	// Test high limit value OPT_FLAG bit 5 or 7 = 1
+	HI_LIMIT R4 `stdf:"flag=OPT_FLAG&0xa0"`
+	// Starting input value (condition) OPT_FLAG bit 1 = 1
+	START_IN R4 `stdf:"flag=OPT_FLAG&0x02"`
+	// Increment of input condition OPT_FLAG bit 1 = 1
+	INCR_IN R4 `stdf:"flag=OPT_FLAG&0x02"`
+	// Array of j PMR indexes RTN_ICNT = 0
+	RTN_INDX KXU2 `stdf:"count=RTN_ICNT,missing=empty"`
+	// Units of returned results length byte = 0
+	UNITS CN `stdf:"missing=empty"`
+	// Input condition units length byte = 0
+	UNITS_IN CN `stdf:"missing=empty"`
+	// ANSI C result format string length byte = 0
+	C_RESFMT CN `stdf:"missing=empty"`
+	// ANSI C low limit format string length byte = 0
+	C_LLMFMT CN `stdf:"missing=empty"`
+	// ANSI C high limit format string length byte = 0
+	C_HLMFMT CN `stdf:"missing=empty"`
+	// Low specification limit value OPT_FLAG bit 2 = 1
+	LO_SPEC R4 `stdf:"flag=OPT_FLAG&0x04"`
+	// High specification limit value OPT_FLAG bit 3 = 1
+	HI_SPEC R4 `stdf:"flag=OPT_FLAG&0x08"`
+}
+
+func (f MPR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f MPR) ToString() string {
+	return f.String()
+}
+
+func (f MPR) String() string {
+	return recordString(&f)
+}
+
+func (f MPR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *MPR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f MPR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Functional Test Record (FTR)
+// Function: Contains the results of the single execution of a functional test in the test program. The
+// first occurrence of this record also establishes the default values for all semi-static
+// information about the test. The FTR is related to the Test Synopsis Record (TSR) by test
+// number, head number, and site number.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (15)
+// REC_SUB U*1 Record sub-type (20)
+// TEST_NUM U*4 Test number
+// HEAD_NUM U*1 Test head number
+// SITE_NUM U*1 Test site number
+// TEST_FLG B*1 Test flags (fail, alarm, etc.)
+// OPT_FLAG B*1 Optional data flag (See note) See note
+// CYCL_CNT U*4 Cycle count of vector OPT_FLAG bit 0 = 1
+// REL_VADR U*4 Relative vector address OPT_FLAG bit 1 = 1
+// REPT_CNT U*4 Repeat count of vector OPT_FLAG bit 2 = 1
+// NUM_FAIL U*4 Number of pins with 1 or more failures OPT_FLAG bit 3 = 1
+// XFAIL_AD I*4 X logical device failure address OPT_FLAG bit 4 = 1
+// YFAIL_AD I*4 Y logical device failure address OPT_FLAG bit 4 = 1
+// VECT_OFF I*2 Offset from vector of interest OPT_FLAG bit 5 = 1
+// RTN_ICNT U*2 Count (j) of return data PMR indexes See note
+// PGM_ICNT U*2 Count (k) of programmed state indexes See note
+// RTN_INDX jxU*2 Array of j return data PMR indexes RTN_ICNT = 0
+// RTN_STAT jxN*1 Array of j returned states RTN_ICNT = 0
+// PGM_INDX kxU*2 Array of k programmed state indexes PGM_ICNT = 0
+// PGM_STAT kxN*1 Array of k programmed states PGM_ICNT = 0
+// FAIL_PIN D*n Failing pin bitfield length bytes = 0
+// VECT_NAM C*n Vector module pattern name length byte = 0
+// TIME_SET C*n Time set name length byte = 0
+// OP_CODE C*n Vector Op Code length byte = 0
+// TEST_TXT C*n Descriptive text or label length byte = 0
+// ALARM_ID C*n Name of alarm length byte = 0
+// PROG_TXT C*n Additional programmed information length byte = 0
+// RSLT_TXT C*n Additional result information length byte = 0
+// PATG_NUM U*1 Pattern generator number 255
+// SPIN_MAP D*n Bit map of enabled comparators length byte = 0
+// Notes on Specific Fields:
+// OPT_FLAG Contains the following fields:
+// bit 0 set = CYCL_CNT data is invalid
+// bit 1 set = REL_VADR data is invalid
+// bit 2 set = REPT_CNT data is invalid
+// bit 3 set = NUM_FAIL data is invalid
+// bit 4 set = XFAIL_AD and YFAIL_AD data are invalid
+// bit 5 set = VECT_OFF data is invalid (offset defaults to 0)
+// bits 6, 7 reserved for future use and must be 1
+// Frequency: One or more for each execution of a functional test.
+// Location: Anywhere in the data stream after the corresponding Part Information Record (PIR)
+// and before the corresponding Part Results Record (PRR).
+// Possible Use: Datalog, Functional Histogram, Functional Failure Analysis
+type FTR struct {
+	BasicRecordType
+	// Test number
+	TEST_NUM U4
+	// Test head number
+	HEAD_NUM U1
+	// Test site number
+	SITE_NUM U1
+	// Test flags (fail, alarm, etc.)
+	TEST_FLG TestFlag
+	// Optional data flag (See note) See note
+	OPT_FLAG B1
+	// Cycle count of vector OPT_FLAG bit 0 = 1
+	CYCL_CNT U4 `stdf:"flag=OPT_FLAG&0x01"`
+	// Relative vector address OPT_FLAG bit 1 = 1
+	REL_VADR U4 `stdf:"flag=OPT_FLAG&0x02"`
+	// Repeat count of vector OPT_FLAG bit 2 = 1
+	REPT_CNT U4 `stdf:"flag=OPT_FLAG&0x04"`
+	// Number of pins with 1 or more failures OPT_FLAG bit 3 = 1
+	NUM_FAIL U4 `stdf:"flag=OPT_FLAG&0x08"`
+	// X logical device failure address OPT_FLAG bit 4 = 1
+	XFAIL_AD I4 `stdf:"flag=OPT_FLAG&0x10"`
+	// Y logical device failure address OPT_FLAG bit 4 = 1
+	YFAIL_AD I4 `stdf:"flag=OPT_FLAG&0x10"`
+	// Offset from vector of interest OPT_FLAG bit 5 = 1
+	VECT_OFF I2 `stdf:"flag=OPT_FLAG&0x20"`
+	// Count (j) of return data PMR indexes See note
+	RTN_ICNT U2
+	// Count (k) of programmed state indexes See note
+	PGM_ICNT U2
+	// Array of j return data PMR indexes RTN_ICNT = 0
+	RTN_INDX KXU2 `stdf:"count=RTN_ICNT,missing=empty"`
+	// Array of j returned states RTN_ICNT = 0
+	RTN_STAT KXN1 `stdf:"count=RTN_ICNT,missing=empty"`
+	// Array of k programmed state indexes PGM_ICNT = 0
+	PGM_INDX KXU2 `stdf:"count=PGM_ICNT,missing=empty"`
+	// Array of k programmed states PGM_ICNT = 0
+	PGM_STAT KXN1 `stdf:"count=PGM_ICNT,missing=empty"`
+	// Failing pin bitfield length bytes = 0
+	FAIL_PIN DN `stdf:"missing=empty"`
+	// Vector module pattern name length byte = 0
+	VECT_NAM CN `stdf:"missing=empty"`
+	// Time set name length byte = 0
+	TIME_SET CN `stdf:"missing=empty"`
+	// Vector Op Code length byte = 0
+	OP_CODE CN `stdf:"missing=empty"`
+	// Descriptive text or label length byte = 0
+	TEST_TXT CN `stdf:"missing=empty"`
+	// Name of alarm length byte = 0
+	ALARM_ID CN `stdf:"missing=empty"`
+	// Additional programmed information length byte = 0
+	PROG_TXT CN `stdf:"missing=empty"`
+	// Additional result information length byte = 0
+	RSLT_TXT CN `stdf:"missing=empty"`
+	// Pattern generator number 255
+	PATG_NUM U1 `stdf:"missing=255"`
+	// Bit map of enabled comparators length byte = 0
+	SPIN_MAP DN `stdf:"missing=empty"`
+}
+
+func (f FTR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f FTR) ToString() string {
+	return f.String()
+}
+
+func (f FTR) String() string {
+	return recordString(&f)
+}
+
+func (f FTR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *FTR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f FTR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Begin Program Section Record (BPS)
+// Function: Marks the beginning of a new program section (or sequencer) in the job plan.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (20)
+// REC_SUB U*1 Record sub-type (10)
+// SEQ_NAME C*n Program section (or sequencer) name length byte = 0
+// Frequency: Optional on each entry into the program segment.
+// Location: Anywhere after the PIR and before the PRR.
+// Possible Use: When performing analyses on a particular program segment's test.
+type BPS struct {
+	BasicRecordType
+	// Program section (or sequencer) name length byte = 0
+	SEQ_NAME CN `stdf:"missing=empty"`
+}
+
+func (f BPS) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f BPS) ToString() string {
+	return f.String()
+}
+
+func (f BPS) String() string {
+	return recordString(&f)
+}
+
+func (f BPS) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *BPS) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f BPS) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// End Program Section Record (EPS)
+// Function: Marks the end of the current program section (or sequencer) in the job plan.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (20)
+// REC_SUB U*1 Record sub-type (20)
+// Frequency: Optional on each exit from the program segment.
+// Location: Following the corresponding BPS and before the PRR in the data stream.
+// Possible Use: When performing analyses on a particular program segment's test.
+type EPS struct {
+	BasicRecordType
+}
+
+func (f EPS) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f EPS) ToString() string {
+	return f.String()
+}
+
+func (f EPS) String() string {
+	return recordString(&f)
+}
+
+func (f EPS) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *EPS) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f EPS) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Generic Data Record (GDR)
+// Function: Contains information that does not conform to any other record type defined by the
+// STDF specification. Such records are intended to be written under the control of job
+// plans executing on the tester. This data may be used for any purpose that the user
+// desires.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (50)
+// REC_SUB U*1 Record sub-type (10)
+// FLD_CNT U*2 Count of data fields in record
+// GEN_DATA V*n Data type code and data for one field (Repeat GEN_DATA for each data field)
+// Frequency: A test program may write any number of GDRs as needed.
+// Location: Anywhere in the data stream after the initial sequence.
+// Possible Use: User-written reports
+type GDR struct {
+	BasicRecordType
+	// Count of data fields in record
+	FLD_CNT U2
+	// Data type code and data for one field
+	GEN_DATA VN `stdf:"count=FLD_CNT"`
+}
+
+func (f GDR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f GDR) ToString() string {
+	return f.String()
+}
+
+func (f GDR) String() string {
+	return recordString(&f)
+}
+
+func (f GDR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *GDR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f GDR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
+
+// Datalog Text Record (DTR)
+// Function: Contains text information that is to be included in the datalog printout. DTRs may be
+// written under the control of a job plan: for example, to highlight unexpected test
+// results. They may also be generated by the tester executive software: for example, to
+// indicate that the datalog sampling rate has changed. DTRs are placed as comments in
+// the datalog listing.
+// Data Fields:
+// Field Data Field Missing/Invalid
+// Name Type Description Data Flag
+// REC_LEN U*2 Bytes of data following header
+// REC_TYP U*1 Record type (50)
+// REC_SUB U*1 Record sub-type (30)
+// TEXT_DAT C*n ASCII text string
+// Frequency: A test program may write any number of DTRs as needed.
+// Location: Anywhere in the data stream after the initial sequence.
+// Possible Use: Datalog
+type DTR struct {
+	BasicRecordType
+	// ASCII text string
+	TEXT_DAT CN
+}
+
+func (f DTR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f DTR) ToString() string {
+	return f.String()
+}
+
+func (f DTR) String() string {
+	return recordString(&f)
+}
+
+func (f DTR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *DTR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f DTR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
@@ -0,0 +1,52 @@
+package stdf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewStdfRecordTypes(t *testing.T) {
+	tests := []struct {
+		typ, sub byte
+		want     string
+	}{
+		{0, 10, "*stdf.FAR"}, {0, 20, "*stdf.ATR"},
+		{1, 10, "*stdf.MIR"}, {1, 20, "*stdf.MRR"}, {1, 30, "*stdf.PCR"}, {1, 40, "*stdf.HBR"},
+		{1, 50, "*stdf.SBR"}, {1, 60, "*stdf.PMR"}, {1, 62, "*stdf.PGR"}, {1, 63, "*stdf.PLR"},
+		{1, 70, "*stdf.RDR"}, {1, 80, "*stdf.SDR"},
+		{2, 10, "*stdf.WIR"}, {2, 20, "*stdf.WRR"}, {2, 30, "*stdf.WCR"},
+		{5, 10, "*stdf.PIR"}, {5, 20, "*stdf.PRR"},
+		{10, 30, "*stdf.TSR"},
+		{15, 10, "*stdf.PTR"}, {15, 15, "*stdf.MPR"}, {15, 20, "*stdf.FTR"},
+		{20, 10, "*stdf.BPS"}, {20, 20, "*stdf.EPS"},
+		{50, 10, "*stdf.GDR"}, {50, 30, "*stdf.DTR"},
+	}
+	for _, tt := range tests {
+		rec := NewStdfRecord([]byte{0, 0, tt.typ, tt.sub})
+		if got := fmt.Sprintf("%T", rec); got != tt.want {
+			t.Errorf("NewStdfRecord(%d, %d) = %s, want %s", tt.typ, tt.sub, got, tt.want)
+		}
+	}
+	if rec := NewStdfRecord([]byte{0, 0, 180, 1}); rec != nil {
+		t.Errorf("NewStdfRecord(180, 1) = %T, want nil", rec)
+	}
+}
+
+func TestFlagBits(t *testing.T) {
+	if f := TestFlag(0x80); !f.Failed() || f.Passed() || !f.ResultValid() {
+		t.Errorf("TEST_FLG %#x: Failed %v, Passed %v, ResultValid %v", f, f.Failed(), f.Passed(), f.ResultValid())
+	}
+	if f := TestFlag(0xc3); f.Failed() || f.Passed() || f.ResultValid() || !f.Alarm() {
+		t.Errorf("TEST_FLG %#x: Failed %v, Passed %v, ResultValid %v, Alarm %v",
+			f, f.Failed(), f.Passed(), f.ResultValid(), f.Alarm())
+	}
+	if f := ParmFlag(0x48); !f.AboveHighLimit() || f.BelowLowLimit() || !f.LowLimitInclusive() {
+		t.Errorf("PARM_FLG %#x decoded wrong", f)
+	}
+	if f := OptFlag(0x52); !f.LowLimitInvalid() || f.HasLowLimit() || !f.HasHighLimit() || !f.InputInvalid() {
+		t.Errorf("OPT_FLAG %#x decoded wrong", f)
+	}
+	if f := PartFlag(0x0e); !f.Retest() || f.RetestByID() || !f.AbnormalEnd() || !f.Failed() {
+		t.Errorf("PART_FLG %#x decoded wrong", f)
+	}
+}
@@ -0,0 +1,278 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+// BinCounts are the parts of a site, or of a whole lot, counted from their
+// PRRs.
+type BinCounts struct {
+	// Parts counts every PRR, retests included; Good those that passed,
+	// Retests those flagged as retests and Aborts those whose testing ended
+	// abnormally
+	Parts, Good, Retests, Aborts int64
+	// Parts by HARD_BIN and by SOFT_BIN; parts without a SOFT_BIN are not
+	// in Soft
+	Hard, Soft map[stdf.U2]int64
+}
+
+func newBinCounts() *BinCounts {
+	return &BinCounts{Hard: make(map[stdf.U2]int64), Soft: make(map[stdf.U2]int64)}
+}
+
+// Yield returns the fraction of the parts that passed, or NaN if there are
+// none.
+func (c *BinCounts) Yield() float64 {
+	if c.Parts == 0 {
+		return math.NaN()
+	}
+	return float64(c.Good) / float64(c.Parts)
+}
+
+func (c *BinCounts) add(p *stdf.PRR) {
+	c.Parts++
+	if p.PART_FLG.Passed() {
+		c.Good++
+	}
+	if p.PART_FLG.Retest() {
+		c.Retests++
+	}
+	if p.PART_FLG.AbnormalEnd() {
+		c.Aborts++
+	}
+	c.Hard[p.HARD_BIN]++
+	if !p.IsMissing("SOFT_BIN") {
+		c.Soft[p.SOFT_BIN]++
+	}
+}
+
+// Site identifies a test site.
+type Site struct {
+	Head, Site stdf.U1
+}
+
+// BinSummary counts the parts of a stream by bin, per site and for the
+// whole lot, and keeps the tester's HBRs, SBRs and PCRs to reconcile the
+// counts with.
+type BinSummary struct {
+	lot   *BinCounts
+	sites map[Site]*BinCounts
+	hbrs  []*stdf.HBR
+	sbrs  []*stdf.SBR
+	pcrs  []*stdf.PCR
+}
+
+// NewBinSummary returns an empty BinSummary.
+func NewBinSummary() *BinSummary {
+	return &BinSummary{lot: newBinCounts(), sites: make(map[Site]*BinCounts)}
+}
+
+// SummarizeBins reads the STDF stream r to its end and counts its parts.
+func SummarizeBins(r io.Reader) (*BinSummary, error) {
+	s := NewBinSummary()
+	rd := stdf.NewReader(r)
+	for {
+		rec, err := rd.Next()
+		if err == io.EOF {
+			return s, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.Add(rec)
+	}
+}
+
+// Add adds rec, a record pointer as returned by stdf.Reader. Records other
+// than PRR, HBR, SBR and PCR are ignored.
+func (s *BinSummary) Add(rec stdf.StdfRecordType) {
+	switch rec := rec.(type) {
+	case *stdf.PRR:
+		k := Site{rec.HEAD_NUM, rec.SITE_NUM}
+		c := s.sites[k]
+		if c == nil {
+			c = newBinCounts()
+			s.sites[k] = c
+		}
+		c.add(rec)
+		s.lot.add(rec)
+	case *stdf.HBR:
+		s.hbrs = append(s.hbrs, rec)
+	case *stdf.SBR:
+		s.sbrs = append(s.sbrs, rec)
+	case *stdf.PCR:
+		s.pcrs = append(s.pcrs, rec)
+	}
+}
+
+// Lot returns the counts of all sites together.
+func (s *BinSummary) Lot() *BinCounts { return s.lot }
+
+// Site returns the counts of a site, or nil if it has no parts.
+func (s *BinSummary) Site(head, site stdf.U1) *BinCounts {
+	return s.sites[Site{head, site}]
+}
+
+// Sites returns the sites with parts, by head and site number.
+func (s *BinSummary) Sites() []Site {
+	sites := make([]Site, 0, len(s.sites))
+	for k := range s.sites {
+		sites = append(sites, k)
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		if sites[i].Head != sites[j].Head {
+			return sites[i].Head < sites[j].Head
+		}
+		return sites[i].Site < sites[j].Site
+	})
+	return sites
+}
+
+// counts returns the counts an HBR, SBR or PCR of head and site covers:
+// HEAD_NUM 255 stands for all sites. It is empty for a site without parts.
+func (s *BinSummary) counts(head, site stdf.U1) *BinCounts {
+	if head == 255 {
+		return s.lot
+	}
+	if c := s.sites[Site{head, site}]; c != nil {
+		return c
+	}
+	return newBinCounts()
+}
+
+// BinMismatch is a count of the tester's HBR, SBR or PCR that disagrees
+// with the parts counted from the PRRs.
+type BinMismatch struct {
+	// Record is HBR, SBR or PCR
+	Record string
+	// Head and site of the record; head 255 is all sites, and its site is
+	// reported as 255 for HBRs and SBRs
+	Head, Site stdf.U1
+	// Field is the count that differs, e.g. HBIN_CNT or GOOD_CNT; for
+	// HBIN_CNT and SBIN_CNT Bin is the bin
+	Field   string
+	Bin     stdf.U2
+	Tester  int64
+	Counted int64
+}
+
+func (m BinMismatch) String() string {
+	s := fmt.Sprintf("%s head %d site %d %s", m.Record, m.Head, m.Site, m.Field)
+	if m.Field == "HBIN_CNT" || m.Field == "SBIN_CNT" {
+		s += fmt.Sprintf(" bin %d", m.Bin)
+	}
+	return fmt.Sprintf("%s: tester %d, counted %d", s, m.Tester, m.Counted)
+}
+
+// binKey is a bin count of an HBR or SBR.
+type binKey struct {
+	head, site stdf.U1
+	bin        stdf.U2
+}
+
+// newBinKey returns the key of a bin of head and site. The SITE_NUM of a
+// record for all sites means nothing, so the site of head 255 is 255.
+func newBinKey(head, site stdf.U1, bin stdf.U2) binKey {
+	if head == 255 {
+		site = 255
+	}
+	return binKey{head, site, bin}
+}
+
+// Reconcile compares the counts with the tester's summary records and
+// returns every difference, HBRs first, then SBRs and PCRs, by head, site
+// and bin.
+//
+// An HBR or SBR is checked against the parts of its site, or of the lot
+// for HEAD_NUM 255, in its bin. Bins the parts of a site, or of the lot,
+// fall into that the tester has no record for are reported with a tester
+// count of 0, as long as the tester wrote bin records for that site or
+// the lot at all. A PCR is checked for its PART_CNT, RTST_CNT, ABRT_CNT
+// and GOOD_CNT, unless they are missing.
+func (s *BinSummary) Reconcile() []BinMismatch {
+	var m []BinMismatch
+	hard := make(map[binKey]int64)
+	for _, r := range s.hbrs {
+		hard[newBinKey(r.HEAD_NUM, r.SITE_NUM, r.HBIN_NUM)] += int64(r.HBIN_CNT)
+	}
+	m = append(m, s.reconcileBins("HBR", "HBIN_CNT", hard, func(c *BinCounts) map[stdf.U2]int64 { return c.Hard })...)
+	soft := make(map[binKey]int64)
+	for _, r := range s.sbrs {
+		soft[newBinKey(r.HEAD_NUM, r.SITE_NUM, r.SBIN_NUM)] += int64(r.SBIN_CNT)
+	}
+	m = append(m, s.reconcileBins("SBR", "SBIN_CNT", soft, func(c *BinCounts) map[stdf.U2]int64 { return c.Soft })...)
+
+	pcrs := append([]*stdf.PCR(nil), s.pcrs...)
+	sort.SliceStable(pcrs, func(i, j int) bool {
+		if pcrs[i].HEAD_NUM != pcrs[j].HEAD_NUM {
+			return pcrs[i].HEAD_NUM < pcrs[j].HEAD_NUM
+		}
+		return pcrs[i].SITE_NUM < pcrs[j].SITE_NUM
+	})
+	for _, r := range pcrs {
+		c := s.counts(r.HEAD_NUM, r.SITE_NUM)
+		for _, f := range []struct {
+			name   string
+			tester stdf.U4
+			count  int64
+		}{
+			{"PART_CNT", r.PART_CNT, c.Parts},
+			{"RTST_CNT", r.RTST_CNT, c.Retests},
+			{"ABRT_CNT", r.ABRT_CNT, c.Aborts},
+			{"GOOD_CNT", r.GOOD_CNT, c.Good},
+		} {
+			if !r.IsMissing(f.name) && int64(f.tester) != f.count {
+				m = append(m, BinMismatch{Record: "PCR", Head: r.HEAD_NUM, Site: r.SITE_NUM, Field: f.name,
+					Tester: int64(f.tester), Counted: f.count})
+			}
+		}
+	}
+	return m
+}
+
+// reconcileBins compares the tester's bin counts with the counted ones that
+// bins picks from the counts of a site.
+func (s *BinSummary) reconcileBins(record, field string, tester map[binKey]int64,
+	bins func(*BinCounts) map[stdf.U2]int64) []BinMismatch {
+	// every bin of a site the tester wrote records for is checked
+	keys := make(map[binKey]bool)
+	sites := make(map[Site]bool)
+	for k := range tester {
+		keys[k] = true
+		sites[Site{k.head, k.site}] = true
+	}
+	for site := range sites {
+		c := s.counts(site.Head, site.Site)
+		for bin := range bins(c) {
+			keys[binKey{site.Head, site.Site, bin}] = true
+		}
+	}
+	sorted := make([]binKey, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.head != b.head {
+			return a.head < b.head
+		}
+		if a.site != b.site {
+			return a.site < b.site
+		}
+		return a.bin < b.bin
+	})
+	var m []BinMismatch
+	for _, k := range sorted {
+		counted := bins(s.counts(k.head, k.site))[k.bin]
+		if tester[k] != counted {
+			m = append(m, BinMismatch{Record: record, Head: k.head, Site: k.site, Field: field, Bin: k.bin,
+				Tester: tester[k], Counted: counted})
+		}
+	}
+	return m
+}
@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+func TestBinSummary(t *testing.T) {
+	var bin bytes.Buffer
+	w := stdf.NewWriter(&bin)
+	prr := func(site stdf.U1, flg stdf.PartFlag, hard, soft stdf.U2) stdf.PRR {
+		return stdf.PRR{HEAD_NUM: 1, SITE_NUM: site, PART_FLG: flg, HARD_BIN: hard, SOFT_BIN: soft,
+			X_COORD: -32768, Y_COORD: -32768}
+	}
+	recs := []stdf.StdfRecordType{
+		stdf.FAR{Cpu_Type: 2, Stdf_Ver: 4},
+		stdf.MIR{MODE_COD: 'P', RTST_COD: ' ', PROT_COD: ' ', BURN_TIM: 65535, CMOD_COD: ' '},
+		prr(1, 0, 1, 1),
+		prr(2, 0x08, 5, 50),
+		prr(1, 0, 1, 2),
+		prr(2, 0x01, 1, 65535),
+		prr(1, 0x08|0x04, 6, 60),
+		// the tester lost a bin 6 part on site 1, and counts one bin 5 part
+		// too many over all sites
+		stdf.HBR{HEAD_NUM: 1, SITE_NUM: 1, HBIN_NUM: 1, HBIN_CNT: 2, HBIN_PF: 'P'},
+		stdf.HBR{HEAD_NUM: 1, SITE_NUM: 2, HBIN_NUM: 1, HBIN_CNT: 1, HBIN_PF: 'P'},
+		stdf.HBR{HEAD_NUM: 1, SITE_NUM: 2, HBIN_NUM: 5, HBIN_CNT: 1, HBIN_PF: 'F'},
+		stdf.HBR{HEAD_NUM: 255, SITE_NUM: 0, HBIN_NUM: 1, HBIN_CNT: 3, HBIN_PF: 'P'},
+		stdf.HBR{HEAD_NUM: 255, SITE_NUM: 0, HBIN_NUM: 5, HBIN_CNT: 2, HBIN_PF: 'F'},
+		stdf.HBR{HEAD_NUM: 255, SITE_NUM: 0, HBIN_NUM: 6, HBIN_CNT: 1, HBIN_PF: 'F'},
+		stdf.SBR{HEAD_NUM: 255, SITE_NUM: 255, SBIN_NUM: 1, SBIN_CNT: 1, SBIN_PF: 'P'},
+		stdf.SBR{HEAD_NUM: 255, SITE_NUM: 255, SBIN_NUM: 2, SBIN_CNT: 1, SBIN_PF: 'P'},
+		stdf.SBR{HEAD_NUM: 255, SITE_NUM: 255, SBIN_NUM: 50, SBIN_CNT: 1, SBIN_PF: 'F'},
+		stdf.SBR{HEAD_NUM: 255, SITE_NUM: 255, SBIN_NUM: 60, SBIN_CNT: 1, SBIN_PF: 'F'},
+		stdf.PCR{HEAD_NUM: 255, SITE_NUM: 0, PART_CNT: 5, RTST_CNT: 1, ABRT_CNT: 1, GOOD_CNT: 3,
+			FUNC_CNT: 4294967295},
+		stdf.PCR{HEAD_NUM: 1, SITE_NUM: 2, PART_CNT: 3, RTST_CNT: 4294967295, ABRT_CNT: 4294967295,
+			GOOD_CNT: 1, FUNC_CNT: 4294967295},
+		stdf.MRR{DISP_COD: ' '},
+	}
+	for _, rec := range recs {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := SummarizeBins(bytes.NewReader(bin.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lot := s.Lot()
+	if lot.Parts != 5 || lot.Good != 3 || lot.Retests != 1 || lot.Aborts != 1 || lot.Yield() != 0.6 {
+		t.Errorf("lot: got %+v, yield %v", lot, lot.Yield())
+	}
+	if lot.Hard[1] != 3 || lot.Hard[5] != 1 || lot.Soft[50] != 1 || len(lot.Soft) != 4 {
+		t.Errorf("lot: got hard bins %v, soft bins %v", lot.Hard, lot.Soft)
+	}
+	if sites := s.Sites(); len(sites) != 2 || sites[0] != (Site{1, 1}) || sites[1] != (Site{1, 2}) {
+		t.Errorf("got sites %v", sites)
+	}
+	if c := s.Site(1, 2); c.Parts != 2 || c.Yield() != 0.5 || c.Hard[5] != 1 {
+		t.Errorf("site 2: got %+v", c)
+	}
+	if s.Site(1, 3) != nil || !math.IsNaN(NewBinSummary().Lot().Yield()) {
+		t.Errorf("empty counts")
+	}
+
+	want := []BinMismatch{
+		{Record: "HBR", Head: 1, Site: 1, Field: "HBIN_CNT", Bin: 6, Tester: 0, Counted: 1},
+		{Record: "HBR", Head: 255, Site: 255, Field: "HBIN_CNT", Bin: 5, Tester: 2, Counted: 1},
+		{Record: "PCR", Head: 1, Site: 2, Field: "PART_CNT", Tester: 3, Counted: 2},
+	}
+	got := s.Reconcile()
+	if len(got) != len(want) {
+		t.Fatalf("got %v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got  %v\nwant %v", got[i], want[i])
+		}
+	}
+	if got, want := want[0].String(), "HBR head 1 site 1 HBIN_CNT bin 6: tester 0, counted 1"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
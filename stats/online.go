@@ -0,0 +1,171 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Online accumulates the count, mean, variance and range of a stream of
+// values in constant memory, with Welford's algorithm so that the
+// variance stays accurate over billions of values. The zero value is
+// ready to use.
+type Online struct {
+	n        int64
+	mean, m2 float64
+	min, max float64
+}
+
+// Add adds x to the stream.
+func (o *Online) Add(x float64) {
+	o.n++
+	if o.n == 1 {
+		o.min, o.max = x, x
+	} else {
+		o.min = math.Min(o.min, x)
+		o.max = math.Max(o.max, x)
+	}
+	d := x - o.mean
+	o.mean += d / float64(o.n)
+	o.m2 += d * (x - o.mean)
+}
+
+// N returns the number of values added.
+func (o *Online) N() int64 { return o.n }
+
+// Mean returns the mean of the values, or NaN if there are none.
+func (o *Online) Mean() float64 {
+	if o.n == 0 {
+		return math.NaN()
+	}
+	return o.mean
+}
+
+// Variance returns the sample variance of the values, or NaN for fewer
+// than two.
+func (o *Online) Variance() float64 {
+	if o.n < 2 {
+		return math.NaN()
+	}
+	return o.m2 / float64(o.n-1)
+}
+
+// StdDev returns the sample standard deviation of the values, or NaN for
+// fewer than two.
+func (o *Online) StdDev() float64 { return math.Sqrt(o.Variance()) }
+
+// Min returns the smallest value, or NaN if there are none.
+func (o *Online) Min() float64 {
+	if o.n == 0 {
+		return math.NaN()
+	}
+	return o.min
+}
+
+// Max returns the largest value, or NaN if there are none.
+func (o *Online) Max() float64 {
+	if o.n == 0 {
+		return math.NaN()
+	}
+	return o.max
+}
+
+// Quantile estimates a quantile of a stream of values in constant memory
+// with the P² algorithm of Jain and Chlamtac, which tracks five markers
+// instead of keeping the values. Up to five values the quantile is exact,
+// interpolated between the two nearest values.
+type Quantile struct {
+	p float64
+	// marker heights, their positions and their desired positions, all
+	// counted from 0
+	q    [5]float64
+	n    [5]int64
+	want [5]float64
+	dn   [5]float64
+	cnt  int64
+}
+
+// NewQuantile returns an estimator of the p quantile, 0 < p < 1; 0.5 is
+// the median. Use Online for the minimum and maximum.
+func NewQuantile(p float64) *Quantile {
+	return &Quantile{
+		p:    p,
+		n:    [5]int64{0, 1, 2, 3, 4},
+		want: [5]float64{0, 2 * p, 4 * p, 2 + 2*p, 4},
+		dn:   [5]float64{0, p / 2, p, (1 + p) / 2, 1},
+	}
+}
+
+// P returns the quantile q estimates.
+func (q *Quantile) P() float64 { return q.p }
+
+// Add adds x to the stream.
+func (q *Quantile) Add(x float64) {
+	if q.cnt < 5 {
+		q.q[q.cnt] = x
+		q.cnt++
+		if q.cnt == 5 {
+			sort.Float64s(q.q[:])
+		}
+		return
+	}
+	q.cnt++
+	var k int
+	switch {
+	case x < q.q[0]:
+		q.q[0] = x
+		k = 0
+	case x >= q.q[4]:
+		q.q[4] = x
+		k = 3
+	default:
+		for k = 0; k < 3 && x >= q.q[k+1]; k++ {
+		}
+	}
+	for i := k + 1; i < 5; i++ {
+		q.n[i]++
+	}
+	for i := range q.want {
+		q.want[i] += q.dn[i]
+	}
+	for i := 1; i < 4; i++ {
+		d := q.want[i] - float64(q.n[i])
+		if d >= 1 && q.n[i+1]-q.n[i] > 1 || d <= -1 && q.n[i-1]-q.n[i] < -1 {
+			s := int64(1)
+			if d < 0 {
+				s = -1
+			}
+			h := q.parabolic(i, s)
+			if q.q[i-1] >= h || h >= q.q[i+1] {
+				h = q.q[i] + float64(s)*(q.q[i+int(s)]-q.q[i])/float64(q.n[i+int(s)]-q.n[i])
+			}
+			q.q[i] = h
+			q.n[i] += s
+		}
+	}
+}
+
+// parabolic returns the piecewise-parabolic prediction for marker i moved
+// by s.
+func (q *Quantile) parabolic(i int, s int64) float64 {
+	d := float64(s)
+	n0, n1, n2 := float64(q.n[i-1]), float64(q.n[i]), float64(q.n[i+1])
+	return q.q[i] + d/(n2-n0)*((n1-n0+d)*(q.q[i+1]-q.q[i])/(n2-n1)+(n2-n1-d)*(q.q[i]-q.q[i-1])/(n1-n0))
+}
+
+// Value returns the estimated quantile, or NaN if no values were added.
+func (q *Quantile) Value() float64 {
+	switch {
+	case q.cnt == 0:
+		return math.NaN()
+	case q.cnt > 5:
+		return q.q[2]
+	}
+	v := append([]float64(nil), q.q[:q.cnt]...)
+	sort.Float64s(v)
+	pos := q.p * float64(len(v)-1)
+	i := int(pos)
+	if i+1 >= len(v) {
+		return v[len(v)-1]
+	}
+	return v[i] + (pos-float64(i))*(v[i+1]-v[i])
+}
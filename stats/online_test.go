@@ -0,0 +1,42 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOnline(t *testing.T) {
+	var o Online
+	if !math.IsNaN(o.Mean()) || !math.IsNaN(o.StdDev()) || !math.IsNaN(o.Min()) {
+		t.Errorf("empty stream: got mean %v, σ %v, min %v", o.Mean(), o.StdDev(), o.Min())
+	}
+	// a large offset ruins the naive sum-of-squares variance
+	for _, x := range []float64{4, 7, 13, 16} {
+		o.Add(1e9 + x)
+	}
+	if o.N() != 4 || o.Mean() != 1e9+10 || o.Variance() != 30 || o.Min() != 1e9+4 || o.Max() != 1e9+16 {
+		t.Errorf("got n %d, mean %v, variance %v, range %v..%v", o.N(), o.Mean(), o.Variance(), o.Min(), o.Max())
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	small := NewQuantile(0.25)
+	for _, x := range []float64{3, 1, 2} {
+		small.Add(x)
+	}
+	if v := small.Value(); v != 1.5 {
+		t.Errorf("3 values: got %v, want 1.5", v)
+	}
+
+	// a well-spread sequence covering [0, 1) evenly
+	const n = 100000
+	for _, p := range []float64{0.01, 0.25, 0.5, 0.9, 0.99} {
+		q := NewQuantile(p)
+		for i := 0; i < n; i++ {
+			q.Add(math.Mod(float64(i)*0.6180339887498949, 1))
+		}
+		if v := q.Value(); math.Abs(v-p) > 0.005 {
+			t.Errorf("p=%v: got %v", p, v)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+// Package stats summarises the test results of STDF files per test
+// number: counts, mean, standard deviation, range, percentiles, process
+// capability against the test limits and fail counts.
+//
+// Everything is computed online, record by record, so a Summary of a file
+// of any size takes memory for its tests only. The counts can be checked
+// against the TSRs the tester wrote, see Summary.CheckTSR.
+//
+// A BinSummary does the same for parts: it counts them by hard and soft
+// bin, per site and for the lot, gives the yield and reconciles the counts
+// with the tester's HBRs, SBRs and PCRs.
+package stats
+
+import (
+	"io"
+	"math"
+	"sort"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+// DefaultPercentiles are the percentiles a Summary estimates when it is
+// given none.
+var DefaultPercentiles = []float64{1, 5, 25, 50, 75, 95, 99}
+
+// Test is the summary of one test number.
+type Test struct {
+	Num stdf.U4
+	// Text is the first non-empty TEST_TXT of the test
+	Text string
+	// Units and limits of the first PTR or MPR of the test, in SI base
+	// units; a limit the record does not have is NaN. FTRs have neither.
+	Units            string
+	LoLimit, HiLimit float64
+	// Executed counts the test's records not flagged "not executed",
+	// Failed and Alarms those of them flagged failed and alarmed.
+	Executed, Failed, Alarms int64
+	// Values holds the valid results in SI base units; an MPR adds each of
+	// its results.
+	Values    Online
+	quantiles []*Quantile
+	limits    bool
+}
+
+// Percentile returns the estimated p-th percentile of the values, for one
+// of the percentiles the Summary was made with. ok is false for others.
+func (t *Test) Percentile(p float64) (v float64, ok bool) {
+	for _, q := range t.quantiles {
+		if q.P() == p/100 {
+			return q.Value(), true
+		}
+	}
+	return math.NaN(), false
+}
+
+// Cp returns the process capability (HiLimit - LoLimit) / 6σ, or NaN if
+// the test lacks a limit or has fewer than two values.
+func (t *Test) Cp() float64 {
+	return (t.HiLimit - t.LoLimit) / (6 * t.Values.StdDev())
+}
+
+// Cpk returns the process capability index, the distance from the mean to
+// the nearer limit over 3σ. A test with one limit is rated against that
+// one; without limits or with fewer than two values Cpk is NaN.
+func (t *Test) Cpk() float64 {
+	m, s := t.Values.Mean(), t.Values.StdDev()
+	hi, lo := (t.HiLimit-m)/(3*s), (m-t.LoLimit)/(3*s)
+	switch {
+	case math.IsNaN(hi):
+		return lo
+	case math.IsNaN(lo):
+		return hi
+	}
+	return math.Min(hi, lo)
+}
+
+// add counts a test record with the given flags and text.
+func (t *Test) add(flg stdf.TestFlag, txt stdf.CN) bool {
+	if t.Text == "" {
+		t.Text = string(txt)
+	}
+	if flg.NotExecuted() {
+		return false
+	}
+	t.Executed++
+	if flg.Failed() {
+		t.Failed++
+	}
+	if flg.Alarm() {
+		t.Alarms++
+	}
+	return flg.ResultValid()
+}
+
+// value adds a valid result.
+func (t *Test) value(v float64) {
+	t.Values.Add(v)
+	for _, q := range t.quantiles {
+		q.Add(v)
+	}
+}
+
+// setLimits takes the units and limits of the first PTR or MPR of the test.
+func (t *Test) setLimits(units stdf.CN, lo stdf.Measure, hasLo bool, hi stdf.Measure, hasHi bool) {
+	if t.limits {
+		return
+	}
+	t.limits = true
+	t.Units = string(units)
+	if hasLo {
+		t.LoLimit = lo.SI()
+	}
+	if hasHi {
+		t.HiLimit = hi.SI()
+	}
+}
+
+// Summary summarises the PTRs, MPRs and FTRs added to it by test number,
+// and keeps the TSRs for CheckTSR.
+type Summary struct {
+	percentiles []float64
+	tests       map[stdf.U4]*Test
+	order       []*Test
+	tsrs        []*stdf.TSR
+}
+
+// NewSummary returns an empty Summary that estimates the given percentiles,
+// between 0 and 100 exclusive, of every test, or DefaultPercentiles if
+// there are none.
+func NewSummary(percentiles ...float64) *Summary {
+	if len(percentiles) == 0 {
+		percentiles = DefaultPercentiles
+	}
+	return &Summary{percentiles: percentiles, tests: make(map[stdf.U4]*Test)}
+}
+
+// Summarize reads the STDF stream r to its end and summarises it.
+func Summarize(r io.Reader, percentiles ...float64) (*Summary, error) {
+	s := NewSummary(percentiles...)
+	rd := stdf.NewReader(r)
+	for {
+		rec, err := rd.Next()
+		if err == io.EOF {
+			return s, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.Add(rec)
+	}
+}
+
+// Add adds rec, a record pointer as returned by stdf.Reader. Records other
+// than PTR, MPR, FTR and TSR are ignored. PTRs should have their default
+// data resolved, as a Reader does.
+func (s *Summary) Add(rec stdf.StdfRecordType) {
+	switch rec := rec.(type) {
+	case *stdf.PTR:
+		t := s.test(rec.TEST_NUM)
+		lo, hasLo := rec.LoLimit()
+		hi, hasHi := rec.HiLimit()
+		t.setLimits(rec.UNITS, lo, hasLo, hi, hasHi)
+		if res, ok := rec.Result(); t.add(rec.TEST_FLG, rec.TEST_TXT) && ok {
+			t.value(res.SI())
+		}
+	case *stdf.MPR:
+		t := s.test(rec.TEST_NUM)
+		lo, hasLo := rec.LoLimit()
+		hi, hasHi := rec.HiLimit()
+		t.setLimits(rec.UNITS, lo, hasLo, hi, hasHi)
+		if res, ok := rec.Results(); t.add(rec.TEST_FLG, rec.TEST_TXT) && ok {
+			for _, m := range res {
+				t.value(m.SI())
+			}
+		}
+	case *stdf.FTR:
+		s.test(rec.TEST_NUM).add(rec.TEST_FLG, rec.TEST_TXT)
+	case *stdf.TSR:
+		s.tsrs = append(s.tsrs, rec)
+	}
+}
+
+// test returns the Test of num, adding it if it is new.
+func (s *Summary) test(num stdf.U4) *Test {
+	t, ok := s.tests[num]
+	if !ok {
+		t = &Test{Num: num, LoLimit: math.NaN(), HiLimit: math.NaN()}
+		for _, p := range s.percentiles {
+			t.quantiles = append(t.quantiles, NewQuantile(p/100))
+		}
+		s.tests[num] = t
+		s.order = append(s.order, t)
+	}
+	return t
+}
+
+// Tests returns the tests in the order they first appeared.
+func (s *Summary) Tests() []*Test {
+	return s.order
+}
+
+// Test returns the summary of test num, or nil if it has no records.
+func (s *Summary) Test(num stdf.U4) *Test {
+	return s.tests[num]
+}
+
+// Mismatch is a count of a Summary that disagrees with the TSRs of its test.
+type Mismatch struct {
+	Num stdf.U4
+	// TSR field name: EXEC_CNT, FAIL_CNT or ALRM_CNT
+	Field   string
+	TSR     int64
+	Counted int64
+}
+
+// CheckTSR compares the execution, fail and alarm counts of each test with
+// the TSRs of the stream, by test number. The tester's summary over all
+// sites, the TSRs with HEAD_NUM 255, is used where there is one; otherwise
+// the TSRs of the single sites are added up. Counts a TSR leaves missing
+// are not checked, nor are tests without TSRs. A test with TSRs but no
+// records is checked against zero counts.
+func (s *Summary) CheckTSR() []Mismatch {
+	type counts struct {
+		all                       bool
+		exec, fail, alrm          int64
+		hasExec, hasFail, hasAlrm bool
+	}
+	sums := make(map[stdf.U4]*counts)
+	var nums []stdf.U4
+	for _, r := range s.tsrs {
+		all := r.HEAD_NUM == 255
+		c := sums[r.TEST_NUM]
+		if c == nil || all && !c.all {
+			if c == nil {
+				nums = append(nums, r.TEST_NUM)
+			}
+			c = &counts{all: all}
+			sums[r.TEST_NUM] = c
+		} else if c.all && !all {
+			continue
+		}
+		if !r.IsMissing("EXEC_CNT") {
+			c.exec, c.hasExec = c.exec+int64(r.EXEC_CNT), true
+		}
+		if !r.IsMissing("FAIL_CNT") {
+			c.fail, c.hasFail = c.fail+int64(r.FAIL_CNT), true
+		}
+		if !r.IsMissing("ALRM_CNT") {
+			c.alrm, c.hasAlrm = c.alrm+int64(r.ALRM_CNT), true
+		}
+	}
+	sort.Slice(nums, func(i, j int) bool { return nums[i] < nums[j] })
+	var m []Mismatch
+	for _, num := range nums {
+		c := sums[num]
+		t := s.tests[num]
+		if t == nil {
+			t = &Test{}
+		}
+		for _, f := range []struct {
+			name       string
+			has        bool
+			tsr, count int64
+		}{
+			{"EXEC_CNT", c.hasExec, c.exec, t.Executed},
+			{"FAIL_CNT", c.hasFail, c.fail, t.Failed},
+			{"ALRM_CNT", c.hasAlrm, c.alrm, t.Alarms},
+		} {
+			if f.has && f.tsr != f.count {
+				m = append(m, Mismatch{Num: num, Field: f.name, TSR: f.tsr, Counted: f.count})
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+func TestSummarize(t *testing.T) {
+	var bin bytes.Buffer
+	w := stdf.NewWriter(&bin)
+	recs := []stdf.StdfRecordType{
+		stdf.FAR{Cpu_Type: 2, Stdf_Ver: 4},
+		stdf.MIR{MODE_COD: 'P', RTST_COD: ' ', PROT_COD: ' ', BURN_TIM: 65535, CMOD_COD: ' '},
+		// the first PTR of test 1 carries limits of 1 and 9 mA
+		stdf.PTR{TEST_NUM: 1, HEAD_NUM: 1, SITE_NUM: 1, RESULT: 0.002, TEST_TXT: stdf.CN("IDD"),
+			OPT_FLAG: 0x0e, RES_SCAL: 3, LLM_SCAL: 3, HLM_SCAL: 3, LO_LIMIT: 0.001, HI_LIMIT: 0.009,
+			UNITS: stdf.CN("A")},
+		stdf.PTR{TEST_NUM: 1, HEAD_NUM: 1, SITE_NUM: 2, RESULT: 0.004},
+		stdf.PTR{TEST_NUM: 1, HEAD_NUM: 1, SITE_NUM: 1, RESULT: 0.006},
+		stdf.PTR{TEST_NUM: 1, HEAD_NUM: 1, SITE_NUM: 2, TEST_FLG: 0x80 | 0x01, RESULT: 0.010},
+		stdf.PTR{TEST_NUM: 1, HEAD_NUM: 1, SITE_NUM: 2, TEST_FLG: 0x02},
+		stdf.PTR{TEST_NUM: 1, HEAD_NUM: 1, SITE_NUM: 2, TEST_FLG: 0x10},
+		// test 2 has a high limit only
+		stdf.PTR{TEST_NUM: 2, HEAD_NUM: 1, SITE_NUM: 1, RESULT: 1, OPT_FLAG: 0x4e, HI_LIMIT: 4},
+		stdf.PTR{TEST_NUM: 2, HEAD_NUM: 1, SITE_NUM: 1, RESULT: 3},
+		stdf.MPR{TEST_NUM: 3, HEAD_NUM: 1, SITE_NUM: 1, RSLT_CNT: 3, RTN_RSLT: stdf.KXR4{1, 2, 3},
+			OPT_FLAG: 0xce},
+		stdf.FTR{TEST_NUM: 4, HEAD_NUM: 1, SITE_NUM: 1, TEST_FLG: 0x80},
+		stdf.FTR{TEST_NUM: 4, HEAD_NUM: 1, SITE_NUM: 2},
+		// per site counts for test 1 add up; the all-site TSR of test 2
+		// wins over its wrong per site one
+		stdf.TSR{HEAD_NUM: 1, SITE_NUM: 1, TEST_NUM: 1, EXEC_CNT: 2, FAIL_CNT: 0, ALRM_CNT: 4294967295},
+		stdf.TSR{HEAD_NUM: 1, SITE_NUM: 2, TEST_NUM: 1, EXEC_CNT: 3, FAIL_CNT: 1, ALRM_CNT: 4294967295},
+		stdf.TSR{HEAD_NUM: 1, SITE_NUM: 1, TEST_NUM: 2, EXEC_CNT: 7, FAIL_CNT: 0, ALRM_CNT: 0},
+		stdf.TSR{HEAD_NUM: 255, SITE_NUM: 0, TEST_NUM: 2, EXEC_CNT: 2, FAIL_CNT: 0, ALRM_CNT: 0},
+		stdf.TSR{HEAD_NUM: 255, SITE_NUM: 0, TEST_NUM: 4, EXEC_CNT: 2, FAIL_CNT: 2, ALRM_CNT: 4294967295},
+		stdf.TSR{HEAD_NUM: 255, SITE_NUM: 0, TEST_NUM: 5, EXEC_CNT: 1, FAIL_CNT: 4294967295, ALRM_CNT: 4294967295},
+		stdf.MRR{DISP_COD: ' '},
+	}
+	for _, rec := range recs {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Summarize(bytes.NewReader(bin.Bytes()), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Tests()) != 4 || s.Tests()[0].Num != 1 || s.Test(5) != nil {
+		t.Fatalf("got tests %+v", s.Tests())
+	}
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	t1 := s.Test(1)
+	if t1.Text != "IDD" || t1.Units != "A" || !near(t1.LoLimit, 0.001) || !near(t1.HiLimit, 0.009) {
+		t.Errorf("test 1: got %q %q limits %v %v", t1.Text, t1.Units, t1.LoLimit, t1.HiLimit)
+	}
+	if t1.Executed != 5 || t1.Failed != 1 || t1.Alarms != 1 || t1.Values.N() != 4 {
+		t.Errorf("test 1: got executed %d, failed %d, alarms %d, values %d",
+			t1.Executed, t1.Failed, t1.Alarms, t1.Values.N())
+	}
+	// results 2, 4, 6 and 10 mA: mean 5.5 mA, σ 3.416 mA
+	sd := math.Sqrt(35.0/3) / 1000
+	if !near(t1.Values.Mean(), 0.0055) || !near(t1.Values.StdDev(), sd) || !near(t1.Values.Max(), 0.010) {
+		t.Errorf("test 1: got mean %v, σ %v, max %v", t1.Values.Mean(), t1.Values.StdDev(), t1.Values.Max())
+	}
+	if med, ok := t1.Percentile(50); !ok || !near(med, 0.005) {
+		t.Errorf("test 1: got median %v, %v", med, ok)
+	}
+	if _, ok := t1.Percentile(90); ok {
+		t.Errorf("test 1 has a 90th percentile")
+	}
+	if !near(t1.Cp(), 0.008/(6*sd)) || !near(t1.Cpk(), 0.0035/(3*sd)) {
+		t.Errorf("test 1: got Cp %v, Cpk %v", t1.Cp(), t1.Cpk())
+	}
+
+	t2 := s.Test(2)
+	if !math.IsNaN(t2.Cp()) || !near(t2.Cpk(), 2/(3*math.Sqrt2)) {
+		t.Errorf("test 2: got Cp %v, Cpk %v", t2.Cp(), t2.Cpk())
+	}
+	t3 := s.Test(3)
+	if t3.Executed != 1 || t3.Values.N() != 3 || !math.IsNaN(t3.Cpk()) {
+		t.Errorf("test 3: got executed %d, values %d, Cpk %v", t3.Executed, t3.Values.N(), t3.Cpk())
+	}
+	t4 := s.Test(4)
+	if t4.Executed != 2 || t4.Failed != 1 || t4.Values.N() != 0 {
+		t.Errorf("test 4: got executed %d, failed %d", t4.Executed, t4.Failed)
+	}
+
+	want := []Mismatch{
+		{Num: 4, Field: "FAIL_CNT", TSR: 2, Counted: 1},
+		{Num: 5, Field: "EXEC_CNT", TSR: 1, Counted: 0},
+	}
+	got := s.CheckTSR()
+	if len(got) != len(want) {
+		t.Fatalf("got mismatches %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %+v, want %+v", got[i], want[i])
+		}
+	}
+}
@@ -2,9 +2,10 @@ package stdf
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"reflect"
-	"time"
+	"unsafe"
 )
 
 type C1 byte
@@ -49,13 +50,76 @@ type R8 float64
 // Fixed length bit-encoded data
 type B6 [6]byte
 
+// One byte of bit-encoded data
+type B1 byte
+
+// Variable length bit-encoded field:
+// first byte = unsigned count of bytes to follow (maximum of 255 bytes).
+// First data item in least significant bit of the second byte of the array
+// (first byte is count.)
+type BN []byte
+
+// Variable length bit-encoded field:
+// first two bytes = unsigned count of bits to follow (maximum of 65,535 bits).
+// First data item in least significant bit of the third byte of the array
+// (first two bytes are count.) Unused bits at the high order end of the last
+// byte must be zero.
+type DN struct {
+	// Number of valid bits in Data
+	Bits U2
+	Data []byte
+}
+
+// Unsigned integer data stored in a nibble. (Nibble = 4 bits of a byte).
+// First item in low 4 bits, second item in high 4 bits.
+type N1 uint8
+
+// One data item of a variable data type field (V*n).
+// The data type is specified by a code in the first byte, and the data
+// follows (maximum of 255 bytes):
+// 0 = B*0 Special pad field, of length 0
+// 1 = U*1 One byte unsigned integer
+// 2 = U*2 Two byte unsigned integer
+// 3 = U*4 Four byte unsigned integer
+// 4 = I*1 One byte signed integer
+// 5 = I*2 Two byte signed integer
+// 6 = I*4 Four byte signed integer
+// 7 = R*4 Four byte floating point number
+// 8 = R*8 Eight byte floating point number
+// 10 = C*n Variable length ASCII character string
+// 11 = B*n Variable length binary data string
+// 12 = D*n Bit encoded data
+// 13 = N*1 Unsigned nibble
+type GenData struct {
+	// Data type code
+	Code U1
+	// Decoded value: U1, U2, U4, I1, I2, I4, R4, R8, CN, BN, DN or N1, nil for a pad
+	Value interface{}
+}
+
+// Variable data type fields (V*n) of a Generic Data Record
+type VN []GenData
+
 type KXU1 []U1
 
+type KXU2 []U2
+
+type KXR4 []R4
+
+// Array of nibbles, two per byte with the first item in the low 4 bits
+type KXN1 []N1
+
+type KXCN []CN
+
 type StdfRecordType interface {
 	// 将对象转换为字节切片输出
 	ToByte() ([]byte, error)
 
 	ToString() string
+
+	// IsMissing reports whether the named field was left out of the record
+	// or holds its Missing/Invalid Data Flag, see FieldStatus.
+	IsMissing(name string) bool
 }
 
 // REC_TYP Code 	Meaning and STDF REC_SUB Codes
@@ -94,7 +158,13 @@ type StdfRecordType interface {
 // 						30 Datalog Text Record (DTR)
 // 180 				Reserved for use by Image software
 // 181 				Reserved for use by IG900 software
+//
+// NewStdfRecord returns nil for unknown record types and for headers
+// shorter than 4 bytes.
 func NewStdfRecord(a []byte) StdfRecordType {
+	if len(a) < 4 {
+		return nil
+	}
 	var t BasicRecordType
 	t.Rec_Len = U2(binary.LittleEndian.Uint16(a))
 	t.Rec_Type = U1(a[2])
@@ -106,6 +176,8 @@ func NewStdfRecord(a []byte) StdfRecordType {
 			var far FAR
 			far.BasicRecordType = t
 			return &far
+		case 20:
+			return &ATR{BasicRecordType: t}
 		}
 	case 1:
 		switch t.Rec_Sub {
@@ -113,81 +185,263 @@ func NewStdfRecord(a []byte) StdfRecordType {
 			var mir MIR
 			mir.BasicRecordType = t
 			return &mir
+		case 20:
+			return &MRR{BasicRecordType: t}
+		case 30:
+			return &PCR{BasicRecordType: t}
+		case 40:
+			return &HBR{BasicRecordType: t}
+		case 50:
+			return &SBR{BasicRecordType: t}
+		case 60:
+			return &PMR{BasicRecordType: t}
+		case 62:
+			return &PGR{BasicRecordType: t}
+		case 63:
+			return &PLR{BasicRecordType: t}
+		case 70:
+			return &RDR{BasicRecordType: t}
 		case 80:
 			var sdr SDR
 			sdr.BasicRecordType = t
 			return &sdr
 		}
+	case 2:
+		switch t.Rec_Sub {
+		case 10:
+			return &WIR{BasicRecordType: t}
+		case 20:
+			return &WRR{BasicRecordType: t}
+		case 30:
+			return &WCR{BasicRecordType: t}
+		}
+	case 5:
+		switch t.Rec_Sub {
+		case 10:
+			return &PIR{BasicRecordType: t}
+		case 20:
+			return &PRR{BasicRecordType: t}
+		}
+	case 10:
+		switch t.Rec_Sub {
+		case 30:
+			return &TSR{BasicRecordType: t}
+		}
+	case 15:
+		switch t.Rec_Sub {
+		case 10:
+			return &PTR{BasicRecordType: t}
+		case 15:
+			return &MPR{BasicRecordType: t}
+		case 20:
+			return &FTR{BasicRecordType: t}
+		}
+	case 20:
+		switch t.Rec_Sub {
+		case 10:
+			return &BPS{BasicRecordType: t}
+		case 20:
+			return &EPS{BasicRecordType: t}
+		}
+	case 50:
+		switch t.Rec_Sub {
+		case 10:
+			return &GDR{BasicRecordType: t}
+		case 30:
+			return &DTR{BasicRecordType: t}
+		}
 	}
 	return nil
 }
 
+// DecodeRecord decodes one complete record, b holding the 4 byte header
+// followed by the REC_LEN bytes of payload. Failures are reported as a
+// *DecodeError wrapping ErrShortRecord, ErrBadLength or ErrUnknownRecord,
+// with offsets counted from the start of b.
+func DecodeRecord(b []byte) (StdfRecordType, error) {
+	return decodeRecord(b, binary.LittleEndian)
+}
+
+// decodeRecord is DecodeRecord for a stream written in the given byte order.
+func decodeRecord(b []byte, order binary.ByteOrder) (StdfRecordType, error) {
+	if len(b) < 4 {
+		return nil, &DecodeError{Offset: int64(len(b)), Err: ErrShortRecord}
+	}
+	typ, sub := U1(b[2]), U1(b[3])
+	n := int(order.Uint16(b))
+	if len(b)-4 < n {
+		return nil, &DecodeError{Offset: int64(len(b)), Rec_Type: typ, Rec_Sub: sub, Err: ErrShortRecord}
+	}
+	// NewStdfRecord reads a little-endian header
+	hdr := [4]byte{byte(n), byte(n >> 8), b[2], b[3]}
+	rec := NewStdfRecord(hdr[:])
+	if rec == nil {
+		return nil, &DecodeError{Offset: 2, Rec_Type: typ, Rec_Sub: sub, Err: ErrUnknownRecord}
+	}
+	if err := transB2S(b[4:4+n], rec, order); err != nil {
+		return nil, shiftOffset(err, 4)
+	}
+	return rec, nil
+}
+
+// RecordCode returns the REC_TYP and REC_SUB codes of the concrete record
+// type of rec, which may be a record struct or a pointer to one.
+// ok is false for types that are not STDF V4 records.
+func RecordCode(rec StdfRecordType) (typ, sub U1, ok bool) {
+	switch rec.(type) {
+	case FAR, *FAR:
+		return 0, 10, true
+	case ATR, *ATR:
+		return 0, 20, true
+	case MIR, *MIR:
+		return 1, 10, true
+	case MRR, *MRR:
+		return 1, 20, true
+	case PCR, *PCR:
+		return 1, 30, true
+	case HBR, *HBR:
+		return 1, 40, true
+	case SBR, *SBR:
+		return 1, 50, true
+	case PMR, *PMR:
+		return 1, 60, true
+	case PGR, *PGR:
+		return 1, 62, true
+	case PLR, *PLR:
+		return 1, 63, true
+	case RDR, *RDR:
+		return 1, 70, true
+	case SDR, *SDR:
+		return 1, 80, true
+	case WIR, *WIR:
+		return 2, 10, true
+	case WRR, *WRR:
+		return 2, 20, true
+	case WCR, *WCR:
+		return 2, 30, true
+	case PIR, *PIR:
+		return 5, 10, true
+	case PRR, *PRR:
+		return 5, 20, true
+	case TSR, *TSR:
+		return 10, 30, true
+	case PTR, *PTR:
+		return 15, 10, true
+	case MPR, *MPR:
+		return 15, 15, true
+	case FTR, *FTR:
+		return 15, 20, true
+	case BPS, *BPS:
+		return 20, 10, true
+	case EPS, *EPS:
+		return 20, 20, true
+	case GDR, *GDR:
+		return 50, 10, true
+	case DTR, *DTR:
+		return 50, 30, true
+	}
+	return 0, 0, false
+}
+
+// TransB2S decodes the record payload s into o1, which must be a pointer to
+// a record struct. Fields are decoded in order until s is used up; fields
+// after the end of s are set to their Missing/Invalid Data Flag, or left
+// as they are if they have none, and IsMissing reports them as missing.
 func TransB2S(s []byte, o1 interface{}) error {
-	t := reflect.TypeOf(o1)
+	return transB2S(s, o1, binary.LittleEndian)
+}
+
+func transB2S(s []byte, o1 interface{}, order binary.ByteOrder) error {
 	v := reflect.ValueOf(o1)
-	m := 0
-	for i := 0; i < t.Elem().NumField(); i++ {
-		if m >= len(s) {
-			break
-		}
-		field := v.Elem().Field(i)
-		fT := fmt.Sprintf("%v", field.Type())
-		switch fT {
-		case "stdf.U1":
-			ii1 := U1(s[m])
-			v.Elem().Field(i).Set(reflect.ValueOf(ii1))
-			m++
-		case "stdf.U2":
-			v.Elem().Field(i).Set(reflect.ValueOf(U2(binary.LittleEndian.Uint16(s[m : m+2]))))
-			m += 2
-		case "stdf.U4":
-			v.Elem().Field(i).Set(reflect.ValueOf(U4(binary.LittleEndian.Uint32(s[m : m+4]))))
-			m += 4
-		case "stdf.C1":
-			ii1 := C1(s[m])
-			v.Elem().Field(i).Set(reflect.ValueOf(ii1))
-			m++
-		case "stdf.CN":
-			i1 := int(s[m])
-			if i1+m > len(s) {
-				v.Elem().Field(i).Set(reflect.ValueOf(CN(s[m:])))
-			} else {
-				v.Elem().Field(i).Set(reflect.ValueOf(CN(s[m : i1+m])))
-			}
-			m = m + 1 + i1
-		case "stdf.KXU1":
-			i1 := v.Elem().Field(i - 1).Interface().(int)
-			var t2 []U1
-			for j := 0; j < int(i1); j++ {
-				t2 = append(t2, U1(s[m+j]))
-			}
-			v.Elem().Field(i).Set(reflect.ValueOf(t2))
-			m = m + i1
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("stdf: TransB2S needs a pointer to a record struct, got %T", o1)
+	}
+	c, err := codecFor(v.Elem().Type())
+	if err != nil {
+		return err
+	}
+	if err := c.decode(s, unsafe.Pointer(v.Pointer()), order == binary.BigEndian); err != nil {
+		var de *DecodeError
+		if rec, ok := o1.(StdfRecordType); ok && errors.As(err, &de) {
+			de.Rec_Type, de.Rec_Sub, _ = RecordCode(rec)
 		}
+		return err
 	}
 	return nil
-	// var params []reflect.Value
-	// params = append(params, reflect.ValueOf(s))
+}
 
-	// v1.MethodByName("ToRecord").Call(params)
-	// t.Log("\n 对象对应的字符串内容:", v1.MethodByName("ToString").Call(nil))
+// TransS2B encodes the data fields of the record o1 (a struct or a pointer
+// to one) to their STDF wire bytes, in field order and little-endian.
+// The record header is not part of the result.
+//
+// Array fields (kxTYPE) are written element by element; the count field
+// they depend on is an ordinary field of the record and is written as is.
+func TransS2B(o1 interface{}) ([]byte, error) {
+	return transS2B(nil, o1, binary.LittleEndian)
+}
 
-	// t.Log("Number of fields", v1.Elem().NumField())
-	// for i := 0; i < v1.Elem().NumField(); i++ {
+// transS2B appends the data fields of o1, encoded in the given byte order, to b.
+func transS2B(b []byte, o1 interface{}, order binary.ByteOrder) ([]byte, error) {
+	v := reflect.ValueOf(o1)
+	if v.Kind() != reflect.Ptr {
+		// the codec works on the record in place, so give it an address
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p
+	}
+	if v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("stdf: TransS2B needs a record struct, got %T", o1)
+	}
+	c, err := codecFor(v.Elem().Type())
+	if err != nil {
+		return nil, err
+	}
+	return c.encode(b, unsafe.Pointer(v.Pointer()), order == binary.BigEndian)
+}
+
+// MaxRecLen is the largest payload REC_LEN can describe.
+const MaxRecLen = 65535
+
+// recordBytes returns the header of rec followed by its encoded fields.
+// REC_TYP and REC_SUB come from the type of rec, as RecordCode reports
+// them, and REC_LEN from the encoded payload; the fields of the embedded
+// BasicRecordType are not used.
+func recordBytes(rec StdfRecordType) ([]byte, error) {
+	typ, sub, ok := RecordCode(rec)
+	if !ok {
+		return nil, fmt.Errorf("stdf: %T is not an STDF record", rec)
+	}
+	return encodeRecord(typ, sub, rec, binary.LittleEndian)
+}
 
-	// 	fmt.Printf("%s: %v = %v\n", field.Name, field.Type, value)
-	// 	t.Logf("Field:%d \t type:%T \t value:%v\n",
-	// 		i, v1.Elem().Field(i), v1.Elem().Field(i))
-	// }
+// encodeRecord returns the header typ/sub followed by the fields of o1,
+// all encoded in the given byte order.
+func encodeRecord(typ, sub U1, o1 interface{}, order binary.ByteOrder) ([]byte, error) {
+	b, err := transS2B(make([]byte, 4, 64), o1, order)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(b) - 4; n > MaxRecLen {
+		return nil, fmt.Errorf("stdf: record %d/%d payload of %d bytes exceeds REC_LEN maximum of %d",
+			typ, sub, n, MaxRecLen)
+	}
+	order.PutUint16(b, uint16(len(b)-4))
+	b[2] = byte(typ)
+	b[3] = byte(sub)
+	return b, nil
 }
 
 type BasicRecordType struct {
-	// Bytes of data following header
+	// Bytes of data following header.
+	// Set by the decoder; ToByte computes it from the encoded payload.
 	Rec_Len U2
 	// Record type (0)
 	Rec_Type U1
 	// REC_SUB U*1 Record sub-type (10)
 	Rec_Sub U1
+	// one more than the number of data fields the decoder found in the
+	// record; 0 if the record was not decoded
+	present int
 }
 
 // File Attributes Record (FAR)
@@ -231,18 +485,39 @@ type FAR struct {
 }
 
 func (f FAR) ToByte() ([]byte, error) {
-	// var b []byte
-	b := make([]byte, 2)
-	binary.LittleEndian.PutUint16(b, uint16(f.Rec_Len))
-	b = append(b, byte(f.Rec_Type))
-	b = append(b, byte(f.Rec_Sub))
-	b = append(b, byte(f.Cpu_Type))
-	b = append(b, byte(f.Stdf_Ver))
-	return b, nil
+	return recordBytes(f)
+}
+
+// ByteOrder returns the byte order of the integer and floating point
+// fields of a file written by CPU_TYPE: big-endian for Sun 1, 2, 3 and 4
+// computers (1) and little-endian otherwise. Files from DEC PDP-11 and VAX
+// processors (0) are read as little-endian, but their F and D floating
+// point formats are not converted.
+func (f FAR) ByteOrder() binary.ByteOrder {
+	if f.Cpu_Type == 1 {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
 }
 
 func (f FAR) ToString() string {
-	return fmt.Sprintf("Rec Len=%v, Rec Type=%v, Rec sub=%v, Cpu Type=%v, Stdf Ver=%v", f.Rec_Len, f.Rec_Type, f.Rec_Sub, f.Cpu_Type, f.Stdf_Ver)
+	return f.String()
+}
+
+func (f FAR) String() string {
+	return recordString(&f)
+}
+
+func (f FAR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *FAR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f FAR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
 }
 
 // Audit Trail Record (ATR)
@@ -265,34 +540,37 @@ func (f FAR) ToString() string {
 // after the FAR (and hence before any other ATRs that may be in the file). In this way,
 // multiple ATRs will be in reverse chronological order.
 // Possible Use: Determining whether a particular filter has been applied to the data.
-// type ATR struct {
-// 	BasicRecordType
-// 	// Date and time of STDF file modification
-// 	MOD_TIM U4
-// 	// Command line of program
-// 	CMD_LINE CN
-// }
-
-// func (f *ATR) ToRecord(b []byte) error {
-// 	f.MOD_TIM = U1(b[4])
-// 	f.CMD_LINE = U1(b[5])
-// 	return nil
-// }
-
-// func (f ATR) ToByte() ([]byte, error) {
-// 	// var b []byte
-// 	b := make([]byte, 2)
-// 	binary.LittleEndian.PutUint16(b, uint16(f.Rec_Len))
-// 	b = append(b, byte(f.Rec_Type))
-// 	b = append(b, byte(f.Rec_Sub))
-// 	b = append(b, byte(f.Cpu_Type))
-// 	b = append(b, byte(f.Stdf_Ver))
-// 	return b, nil
-// }
-
-// func (f ATR) ToString() string {
-// 	return fmt.Sprintf("Rec Len=%v, Rec Type=%v, Rec Type=%v, Cpu Type=%v, Stdf Ver=%v", f.Rec_Len, f.Rec_Type, f.Rec_Type, f.Cpu_Type, f.Stdf_Ver)
-// }
+type ATR struct {
+	BasicRecordType
+	// Date and time of STDF file modification
+	MOD_TIM Timestamp `stdf:"missing=0"`
+	// Command line of program
+	CMD_LINE CN
+}
+
+func (f ATR) ToByte() ([]byte, error) {
+	return recordBytes(f)
+}
+
+func (f ATR) ToString() string {
+	return f.String()
+}
+
+func (f ATR) String() string {
+	return recordString(&f)
+}
+
+func (f ATR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *ATR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f ATR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
+}
 
 // Master Information Record (MIR)
 // Function: The MIR and the MRR (Master Results Record) contain all the global information that
@@ -379,21 +657,21 @@ func (f FAR) ToString() string {
 type MIR struct {
 	BasicRecordType
 	// Date and time of job setup
-	SETUP_T U4
+	SETUP_T Timestamp `stdf:"missing=0"`
 	// Date and time first part tested
-	START_T U4
+	START_T Timestamp `stdf:"missing=0"`
 	// Tester station number
 	STAT_NUM U1
 	// Test mode code (e.g. prod, dev) space
-	MODE_COD C1
+	MODE_COD C1 `stdf:"missing=space"`
 	// Lot retest code space
-	RTST_COD C1
+	RTST_COD C1 `stdf:"missing=space"`
 	// Data protection code space
-	PROT_COD C1
+	PROT_COD C1 `stdf:"missing=space"`
 	// Burn-in time (in minutes) 65,535
-	BURN_TIM U2
+	BURN_TIM U2 `stdf:"missing=65535"`
 	// Command mode code space
-	CMOD_COD C1
+	CMOD_COD C1 `stdf:"missing=space"`
 	// Lot ID (customer specified)
 	LOT_ID CN
 	// Part Type (or product ID)
@@ -405,73 +683,79 @@ type MIR struct {
 	// Job name (test program name)
 	JOB_NAM CN
 	// Job (test program) revision number length byte = 0
-	JOB_REV CN
+	JOB_REV CN `stdf:"missing=empty"`
 	// Sublot ID length byte = 0
-	SBLOT_ID CN
+	SBLOT_ID CN `stdf:"missing=empty"`
 	// Operator name or ID (at setup time) length byte = 0
-	OPER_NAM CN
+	OPER_NAM CN `stdf:"missing=empty"`
 	// Tester executive software type length byte = 0
-	EXEC_TYP CN
+	EXEC_TYP CN `stdf:"missing=empty"`
 	// Tester exec software version number length byte = 0
-	EXEC_VER CN
+	EXEC_VER CN `stdf:"missing=empty"`
 	// Test phase or step code length byte = 0
-	TEST_COD CN
+	TEST_COD CN `stdf:"missing=empty"`
 	// Test temperature length byte = 0
-	TST_TEMP CN
+	TST_TEMP CN `stdf:"missing=empty"`
 	// Generic user text length byte = 0
-	USER_TXT CN
+	USER_TXT CN `stdf:"missing=empty"`
 	// Name of auxiliary data file length byte = 0
-	AUX_FILE CN
+	AUX_FILE CN `stdf:"missing=empty"`
 	// Package type length byte = 0
-	PKG_TYP CN
+	PKG_TYP CN `stdf:"missing=empty"`
 	// Product family ID length byte = 0
-	FAMLY_ID CN
+	FAMLY_ID CN `stdf:"missing=empty"`
 	// Date code length byte = 0
-	DATE_COD CN
+	DATE_COD CN `stdf:"missing=empty"`
 	// Test facility ID length byte = 0
-	FACIL_ID CN
+	FACIL_ID CN `stdf:"missing=empty"`
 	// Test floor ID length byte = 0
-	FLOOR_ID CN
+	FLOOR_ID CN `stdf:"missing=empty"`
 	// Fabrication process ID length byte = 0
-	PROC_ID CN
+	PROC_ID CN `stdf:"missing=empty"`
 	// Operation frequency or step length byte = 0
-	OPER_FRQ CN
+	OPER_FRQ CN `stdf:"missing=empty"`
 	// Test specification name length byte = 0
-	SPEC_NAM CN
+	SPEC_NAM CN `stdf:"missing=empty"`
 	// Test specification version number length byte = 0
-	SPEC_VER CN
+	SPEC_VER CN `stdf:"missing=empty"`
 	// Test flow ID length byte = 0
-	FLOW_ID CN
+	FLOW_ID CN `stdf:"missing=empty"`
 	// Test setup ID length byte = 0
-	SETUP_ID CN
+	SETUP_ID CN `stdf:"missing=empty"`
 	// Device design revision length byte = 0
-	DSGN_REV CN
+	DSGN_REV CN `stdf:"missing=empty"`
 	// Engineering lot ID length byte = 0
-	ENG_ID CN
+	ENG_ID CN `stdf:"missing=empty"`
 	// ROM code ID length byte = 0
-	ROM_COD CN
+	ROM_COD CN `stdf:"missing=empty"`
 	// Tester serial number length byte = 0
-	SERL_NUM CN
+	SERL_NUM CN `stdf:"missing=empty"`
 	// Supervisor name or ID length byte = 0
-	SUPR_NAM CN
+	SUPR_NAM CN `stdf:"missing=empty"`
 }
 
 func (f MIR) ToByte() ([]byte, error) {
-	// var b []byte
-	b := make([]byte, 2)
-
-	// b = append(b, byte(f.Rec_Type))
-	// b = append(b, byte(f.Rec_Sub))
-	// binary.LittleEndian.PutUint16(b, uint16(f.SETUP_T))
-	// b = append(b, byte(f.SETUP_T))
-	// b = append(b, byte(f.Stdf_Ver))
-	return b, nil
+	return recordBytes(f)
 }
 
 func (f MIR) ToString() string {
-	// return ""
-	return fmt.Sprintf("Rec Len=%v, Rec Type=%v, Rec Sub=%v, SETUP_T=%v, LOT_ID=%v",
-		f.Rec_Len, f.Rec_Type, f.Rec_Sub, time.Unix(int64(f.SETUP_T), 0), string(f.LOT_ID))
+	return f.String()
+}
+
+func (f MIR) String() string {
+	return recordString(&f)
+}
+
+func (f MIR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *MIR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f MIR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
 }
 
 // Site Description Record (SDR)
@@ -528,55 +812,61 @@ type SDR struct {
 	// Number (k) of test sites in site group
 	SITE_CNT U1
 	// Array of test site numbers
-	SITE_NUM KXU1
+	SITE_NUM KXU1 `stdf:"count=SITE_CNT"`
 	// Handler or prober type length byte = 0
-	HAND_TYP CN
+	HAND_TYP CN `stdf:"missing=empty"`
 	// Handler or prober ID length byte = 0
-	HAND_ID CN
+	HAND_ID CN `stdf:"missing=empty"`
 	// Probe card type length byte = 0
-	CARD_TYP CN
+	CARD_TYP CN `stdf:"missing=empty"`
 	// Probe card ID length byte = 0
-	CARD_ID CN
+	CARD_ID CN `stdf:"missing=empty"`
 	// Load board type length byte = 0
-	LOAD_TYP CN
+	LOAD_TYP CN `stdf:"missing=empty"`
 	// Load board ID length byte = 0
-	LOAD_ID CN
+	LOAD_ID CN `stdf:"missing=empty"`
 	// DIB board type length byte = 0
-	DIB_TYP CN
+	DIB_TYP CN `stdf:"missing=empty"`
 	// DIB board ID length byte = 0
-	DIB_ID CN
+	DIB_ID CN `stdf:"missing=empty"`
 	// Interface cable type length byte = 0
-	CABL_TYP CN
+	CABL_TYP CN `stdf:"missing=empty"`
 	// Interface cable ID length byte = 0
-	CABL_ID CN
+	CABL_ID CN `stdf:"missing=empty"`
 	// Handler contactor type length byte = 0
-	CONT_TYP CN
+	CONT_TYP CN `stdf:"missing=empty"`
 	// Handler contactor ID length byte = 0
-	CONT_ID CN
+	CONT_ID CN `stdf:"missing=empty"`
 	// Laser type length byte = 0
-	LASR_TYP CN
+	LASR_TYP CN `stdf:"missing=empty"`
 	// Laser ID length byte = 0
-	LASR_ID CN
+	LASR_ID CN `stdf:"missing=empty"`
 	// Extra equipment type field length byte = 0
-	EXTR_TYP CN
+	EXTR_TYP CN `stdf:"missing=empty"`
 	// Extra equipment ID length byte = 0
-	EXTR_ID CN
+	EXTR_ID CN `stdf:"missing=empty"`
 }
 
 func (f SDR) ToByte() ([]byte, error) {
-	// var b []byte
-	b := make([]byte, 2)
-
-	// b = append(b, byte(f.Rec_Type))
-	// b = append(b, byte(f.Rec_Sub))
-	// binary.LittleEndian.PutUint16(b, uint16(f.SETUP_T))
-	// b = append(b, byte(f.SETUP_T))
-	// b = append(b, byte(f.Stdf_Ver))
-	return b, nil
+	return recordBytes(f)
 }
 
 func (f SDR) ToString() string {
-	// return ""
-	return fmt.Sprintf("Rec Len=%v, Rec Type=%v, Rec Sub=%v, SETUP_T=%v, LOT_ID=%v",
-		f.Rec_Len, f.Rec_Type, f.Rec_Sub, f.HEAD_NUM, f.SITE_GRP)
+	return f.String()
+}
+
+func (f SDR) String() string {
+	return recordString(&f)
+}
+
+func (f SDR) MarshalJSON() ([]byte, error) {
+	return marshalRecord(&f)
+}
+
+func (f *SDR) UnmarshalJSON(b []byte) error {
+	return unmarshalRecord(b, f)
+}
+
+func (f SDR) IsMissing(name string) bool {
+	return FieldStatus(&f, name) != FieldPresent
 }
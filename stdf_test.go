@@ -1,7 +1,8 @@
 package stdf
 
 import (
-	"log"
+	"bytes"
+	"io"
 	"os"
 	"reflect"
 	"testing"
@@ -9,60 +10,188 @@ import (
 
 func TestAnalysis(t *testing.T) {
 	//读取stdf内容
-	f, err := os.OpenFile("./mock/HF0062B_V1_F4DUT_TEST_P11464__09_06282021_101511.stdf", os.O_RDONLY, 0755)
+	f, err := os.Open("./mock/HF0062B_V1_F4DUT_TEST_P11464__09_06282021_101511.stdf")
+	if os.IsNotExist(err) {
+		t.Skip("mock datalog not available")
+	}
 	if err != nil {
-		log.Fatal(err)
+		t.Fatal(err)
 	}
-	defer func() {
-		if err := f.Close(); err != nil {
-			log.Fatal(err)
+	defer f.Close()
+
+	r := NewReader(f)
+	for mI := 0; mI < 3; mI++ {
+		o1, err := r.Next()
+		if err == io.EOF {
+			break
 		}
-	}()
-	b1 := make([]byte, 4)
-	index := 0
-	mI := 0
-	// var sg sync.WaitGroup
-	for {
-		f.ReadAt(b1, int64(index))
-		// 通过4个字节串生成一个stdf结构对象
-		t.Log("\n 结构头:", b1)
-		o1 := NewStdfRecord(b1)
-		if o1 == nil {
-			return
+		if err != nil {
+			t.Fatal(err)
 		}
-		// 通过反射获取对象
-		v1 := reflect.ValueOf(o1)
-		// t1 := reflect.TypeOf(o1)
-		// 创建一个content的byte切片
-		ii := v1.Elem().FieldByName("Rec_Len").Uint()
-		t.Log("\n 对象对应的内容长度字节数:", ii)
-		b2 := make([]byte, ii)
-		index += 4
-		f.ReadAt(b2, int64(index))
-		t.Log("\n 对象对应的字节内容:", b2)
-		TransB2S(b2, o1)
-		// var params []reflect.Value
-		// params = append(params, reflect.ValueOf(b2))
-		// v1.MethodByName("ToRecord").Call(params)
-		t.Log("\n 对象对应的字符串内容:", v1.MethodByName("ToString").Call(nil))
+		t.Log("\n 对象对应的字符串内容:", o1.ToString())
+	}
+}
 
-		// t.Log("Number of fields", v1.Elem().NumField())
-		// for i := 0; i < v1.Elem().NumField(); i++ {
-		// 	field := t1.Elem().Field(i)
-		// 	value := v1.Elem().Field(i).Interface()
-		// 	fmt.Printf("%s: %v = %v\n", field.Name, field.Type, value)
-		// 	t.Logf("Field:%d \t type:%T \t value:%v\n",
-		// 		i, v1.Elem().Field(i), v1.Elem().Field(i))
-		// }
+func TestToByteRoundTrip(t *testing.T) {
+	tests := [][]byte{
+		{2, 0, 0, 10, 2, 4},
+		append([]byte{47, 0, 1, 10, 1, 0, 0, 0, 2, 0, 0, 0, 7, 'P', 'N', ' ', 0xff, 0xff, ' ', 2, 'L', '1'}, make([]byte, 29)...),
+		{11, 0, 1, 40, 255, 0, 3, 0, 9, 0, 0, 0, 'F', 1, 'x'},
+		{9, 0, 2, 10, 1, 255, 0x10, 0, 0, 0, 2, 'W', '1'},
+		{2, 0, 5, 10, 1, 3},
+	}
+	for _, b := range tests {
+		rec := NewStdfRecord(b[:4])
+		if err := TransB2S(b[4:], rec); err != nil {
+			t.Fatal(err)
+		}
+		got, err := rec.ToByte()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, b) {
+			t.Errorf("%T round trip:\n got %v\nwant %v", rec, got, b)
+		}
+	}
+}
 
-		index += int(ii)
-		mI++
-		if mI == 3 {
-			break
+func TestToByteArrays(t *testing.T) {
+	ftr := FTR{
+		BasicRecordType: BasicRecordType{Rec_Type: 15, Rec_Sub: 20},
+		RTN_ICNT:        3,
+		RTN_INDX:        KXU2{1, 2, 0x0102},
+		RTN_STAT:        KXN1{1, 2, 3},
+		FAIL_PIN:        DN{Bits: 9, Data: []byte{0xff, 0x01}},
+		TEST_TXT:        CN("ab"),
+		PATG_NUM:        255,
+	}
+	b, err := TransS2B(ftr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		0, 0, 0, 0, 0, 0, 0, 0, // TEST_NUM .. OPT_FLAG
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // CYCL_CNT .. VECT_OFF
+		3, 0, 0, 0, // RTN_ICNT, PGM_ICNT
+		1, 0, 2, 0, 2, 1, // RTN_INDX
+		0x21, 0x03, // RTN_STAT
+		9, 0, 0xff, 0x01, // FAIL_PIN
+		0, 0, 0, 2, 'a', 'b', // VECT_NAM .. TEST_TXT
+		// ALARM_ID .. SPIN_MAP are missing and left out
+	}
+	if !bytes.Equal(b, want) {
+		t.Errorf("got  %v\nwant %v", b, want)
+	}
+
+	if _, err := TransS2B(DTR{TEXT_DAT: make(CN, 256)}); err == nil {
+		t.Error("expected an error for a C*n longer than 255 bytes")
+	}
+}
+
+func TestToByteRecLen(t *testing.T) {
+	dtr := DTR{BasicRecordType: BasicRecordType{Rec_Len: 99, Rec_Type: 50, Rec_Sub: 30}, TEXT_DAT: CN("hello")}
+	b, err := dtr.ToByte()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{6, 0, 50, 30, 5, 'h', 'e', 'l', 'l', 'o'}; !bytes.Equal(b, want) {
+		t.Errorf("got %v, want %v", b, want)
+	}
+
+	gdr := GDR{BasicRecordType: BasicRecordType{Rec_Type: 50, Rec_Sub: 10}}
+	for i := 0; i < 300; i++ {
+		gdr.GEN_DATA = append(gdr.GEN_DATA, GenData{Code: 10, Value: make(CN, 255)})
+	}
+	gdr.FLD_CNT = U2(len(gdr.GEN_DATA))
+	if _, err := gdr.ToByte(); err == nil {
+		t.Error("expected an error for a payload over 65535 bytes")
+	}
+}
+
+func TestToByteHeaderFromType(t *testing.T) {
+	// records built without a BasicRecordType still get their own codes
+	for _, tc := range []struct {
+		rec      StdfRecordType
+		typ, sub byte
+	}{
+		{MIR{LOT_ID: CN("L")}, 1, 10},
+		{&PTR{TEST_NUM: 1}, 15, 10},
+		{DTR{BasicRecordType: BasicRecordType{Rec_Type: 0, Rec_Sub: 10}}, 50, 30},
+	} {
+		b, err := tc.rec.ToByte()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b[2] != tc.typ || b[3] != tc.sub {
+			t.Errorf("%T header %v, want %d/%d", tc.rec, b[:4], tc.typ, tc.sub)
+		}
+	}
+}
+
+func TestTransB2SPrimitives(t *testing.T) {
+	ptr := PTR{
+		TEST_NUM: 100, HEAD_NUM: 1, SITE_NUM: 2, TEST_FLG: 0x80, RESULT: -1.5,
+		TEST_TXT: CN("vdd"), OPT_FLAG: 0x02, RES_SCAL: -3, LLM_SCAL: -3, HLM_SCAL: -3,
+		LO_LIMIT: -2, HI_LIMIT: 2.25, UNITS: CN("V"), LO_SPEC: 1e-3, HI_SPEC: 3,
+	}
+	gdr := GDR{FLD_CNT: 6, GEN_DATA: VN{
+		{Code: 0},
+		{Code: 5, Value: I2(-7)},
+		{Code: 8, Value: R8(2.5)},
+		{Code: 10, Value: CN("txt")},
+		{Code: 12, Value: DN{Bits: 3, Data: []byte{5}}},
+		{Code: 13, Value: N1(9)},
+	}}
+	recs := []StdfRecordType{
+		&ptr,
+		&gdr,
+		&PGR{GRP_INDX: 32768, GRP_NAM: CN("g"), INDX_CNT: 2, PMR_INDX: KXU2{1, 2}},
+		&WCR{WAFR_SIZ: 200, WF_UNITS: 3, WF_FLAT: 'D', CENTER_X: -32768, CENTER_Y: 5, POS_X: 'R', POS_Y: 'D'},
+		&PRR{HEAD_NUM: 1, X_COORD: -4, Y_COORD: 7, TEST_T: 12, PART_FIX: BN{1, 2}},
+	}
+	for _, rec := range recs {
+		b, err := TransS2B(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := reflect.New(reflect.TypeOf(rec).Elem()).Interface().(StdfRecordType)
+		if err := TransB2S(b, got); err != nil {
+			t.Fatal(err)
 		}
+		b2, err := TransS2B(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, b2) {
+			t.Errorf("%T round trip:\n got %v\nwant %v", rec, b2, b)
+		}
+	}
 
-		// f.ReadAt()
+	var p PTR
+	b, _ := TransS2B(ptr)
+	TransB2S(b, &p)
+	if p.RESULT != -1.5 || p.RES_SCAL != -3 || p.HI_LIMIT != 2.25 || string(p.UNITS) != "V" || p.HI_SPEC != 3 {
+		t.Errorf("unexpected PTR %+v", p)
+	}
+	var g GDR
+	b, _ = TransS2B(gdr)
+	TransB2S(b, &g)
+	if !reflect.DeepEqual(g.GEN_DATA[1:3], gdr.GEN_DATA[1:3]) || g.GEN_DATA[5].Value != N1(9) {
+		t.Errorf("unexpected GEN_DATA %v", g.GEN_DATA)
+	}
+}
+
+func TestTransS2BGenDataMismatch(t *testing.T) {
+	for _, g := range []GenData{
+		{Code: 1, Value: U2(3)},
+		{Code: 10, Value: BN("x")},
+		{Code: 0, Value: U1(1)},
+		{Code: 4},
+		{Code: 1, Value: 3},
+	} {
+		gdr := GDR{FLD_CNT: 1, GEN_DATA: VN{g}}
+		if _, err := TransS2B(gdr); err == nil {
+			t.Errorf("no error for %+v", g)
+		}
 	}
-	// sg.Wait()
-	// fmt.Println(far)
 }
@@ -0,0 +1,50 @@
+package stdf
+
+import "time"
+
+// Timestamp is an STDF date and time: a U*4 count of seconds since
+// 1970-01-01 00:00:00, where 0 means the time is missing.
+//
+// Testers write their own wall clock without saying which time zone it
+// was in, so a Timestamp is not an instant until that zone is given, see
+// In and NewTimestamp. Both take the tester's zone as a *time.Location,
+// where nil stands for UTC.
+type Timestamp U4
+
+// NewTimestamp returns the Timestamp a tester whose clock runs in loc
+// records for t. The zero time.Time gives the missing Timestamp 0.
+func NewTimestamp(t time.Time, loc *time.Location) Timestamp {
+	if t.IsZero() {
+		return 0
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	return Timestamp(wall.Unix())
+}
+
+// IsZero reports whether the timestamp is missing.
+func (t Timestamp) IsZero() bool { return t == 0 }
+
+// In returns the instant t stands for when the tester clock ran in loc.
+// A missing timestamp gives the zero time.Time.
+func (t Timestamp) In(loc *time.Location) time.Time {
+	if t == 0 {
+		return time.Time{}
+	}
+	w := time.Unix(int64(t), 0).UTC()
+	if loc == nil {
+		return w
+	}
+	return time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), 0, loc)
+}
+
+// String formats the tester wall clock time, e.g. "2024-03-01 13:45:00".
+func (t Timestamp) String() string {
+	if t == 0 {
+		return "missing"
+	}
+	return t.In(nil).Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,38 @@
+package stdf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimestamp(t *testing.T) {
+	taipei := time.FixedZone("CST", 8*3600)
+	at := time.Date(2024, 3, 1, 13, 45, 0, 0, taipei)
+	ts := NewTimestamp(at, taipei)
+	if got := ts.String(); got != "2024-03-01 13:45:00" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := ts.In(taipei); !got.Equal(at) {
+		t.Errorf("In(taipei) = %v, want %v", got, at)
+	}
+	// the same clock reading taken as UTC is 8 hours later
+	if got := ts.In(nil); got.Sub(at) != 8*time.Hour {
+		t.Errorf("In(nil) = %v", got)
+	}
+	// a tester in UTC records the same instant differently
+	if got := NewTimestamp(at, time.UTC); got.In(nil).Hour() != 5 {
+		t.Errorf("NewTimestamp(UTC) = %v", got)
+	}
+	// nil is UTC for NewTimestamp too, not the zone of at
+	if got := NewTimestamp(at, nil); got != NewTimestamp(at, time.UTC) {
+		t.Errorf("NewTimestamp(nil) = %v", got)
+	}
+
+	var zero Timestamp
+	if !zero.IsZero() || !zero.In(taipei).IsZero() || NewTimestamp(time.Time{}, taipei) != 0 {
+		t.Error("0 is not treated as missing")
+	}
+	if (MRR{FINISH_T: ts}).IsMissing("FINISH_T") || !(MRR{}).IsMissing("FINISH_T") {
+		t.Error("FINISH_T missing state is wrong")
+	}
+}
@@ -0,0 +1,159 @@
+package stdf
+
+import (
+	"math"
+	"strconv"
+)
+
+// Measure is a PTR or MPR value together with the scaling exponent and
+// unit string it is displayed with.
+//
+// STDF stores results and limits in SI base units (e.g. 0.0012 for 1.2 mA,
+// UNITS "A"); the scaling exponent only says how the tester displays them:
+// the displayed value is Value × 10^Scale with the prefix of Scale in front
+// of the unit.
+type Measure struct {
+	Value R4
+	Scale I1
+	Units string
+}
+
+// unitPrefixes are the scaling exponents the STDF specification defines.
+var unitPrefixes = map[I1]string{
+	15:  "f",
+	12:  "p",
+	9:   "n",
+	6:   "u",
+	3:   "m",
+	2:   "%",
+	0:   "",
+	-3:  "K",
+	-6:  "M",
+	-9:  "G",
+	-12: "T",
+}
+
+// SI returns the value in SI base units.
+func (m Measure) SI() float64 {
+	return decimal(m.Value)
+}
+
+// Display returns the value and unit the tester displays, e.g. 1.2 and "mA".
+// Exponents without a prefix in the specification are not applied.
+func (m Measure) Display() (float64, string) {
+	p, ok := unitPrefixes[m.Scale]
+	if !ok {
+		return m.SI(), m.Units
+	}
+	return m.SI() * math.Pow10(int(m.Scale)), p + m.Units
+}
+
+// String formats the displayed value and unit, e.g. "1.2 mA".
+func (m Measure) String() string {
+	v, u := m.Display()
+	s := strconv.FormatFloat(float64(float32(v)), 'g', -1, 32)
+	if u == "" {
+		return s
+	}
+	return s + " " + u
+}
+
+// decimal widens r to float64 through its shortest decimal form, so 0.0012
+// stays 0.0012 instead of picking up float32 noise.
+func decimal(r R4) float64 {
+	v, _ := strconv.ParseFloat(strconv.FormatFloat(float64(r), 'g', -1, 32), 64)
+	return v
+}
+
+// scale returns the scaling exponent s, or 0 if invalid says it is not to
+// be used.
+func scale(s I1, invalid bool) I1 {
+	if invalid {
+		return 0
+	}
+	return s
+}
+
+// specScale returns the exponent a spec is displayed with: specs have
+// none of their own, so they take the one of their limit, or RES_SCAL if
+// that is invalid too.
+func specScale(lim I1, limInvalid bool, res I1, resInvalid bool) I1 {
+	if limInvalid {
+		return scale(res, resInvalid)
+	}
+	return lim
+}
+
+// Result returns RESULT scaled with RES_SCAL. ok is false if the result is
+// flagged invalid or the test was not executed.
+func (f PTR) Result() (m Measure, ok bool) {
+	m = Measure{f.RESULT, scale(f.RES_SCAL, f.OPT_FLAG.ResScaleInvalid()), string(f.UNITS)}
+	return m, !f.IsMissing("RESULT") && !f.TEST_FLG.NotExecuted()
+}
+
+// LoLimit returns LO_LIMIT scaled with LLM_SCAL. ok is false if the PTR
+// has no low limit or OPT_FLAG says it has none or it is invalid.
+func (f PTR) LoLimit() (m Measure, ok bool) {
+	m = Measure{f.LO_LIMIT, scale(f.LLM_SCAL, f.OPT_FLAG.LowLimitInvalid()), string(f.UNITS)}
+	return m, !f.IsMissing("LO_LIMIT")
+}
+
+// HiLimit returns HI_LIMIT scaled with HLM_SCAL. ok is false if the PTR
+// has no high limit or OPT_FLAG says it has none or it is invalid.
+func (f PTR) HiLimit() (m Measure, ok bool) {
+	m = Measure{f.HI_LIMIT, scale(f.HLM_SCAL, f.OPT_FLAG.HighLimitInvalid()), string(f.UNITS)}
+	return m, !f.IsMissing("HI_LIMIT")
+}
+
+// LoSpec returns LO_SPEC, scaled like the low limit. ok is false if the
+// PTR has no low spec or OPT_FLAG says so (bit 2).
+func (f PTR) LoSpec() (m Measure, ok bool) {
+	s := specScale(f.LLM_SCAL, f.OPT_FLAG.LowLimitInvalid(), f.RES_SCAL, f.OPT_FLAG.ResScaleInvalid())
+	return Measure{f.LO_SPEC, s, string(f.UNITS)}, !f.IsMissing("LO_SPEC")
+}
+
+// HiSpec returns HI_SPEC, scaled like the high limit. ok is false if the
+// PTR has no high spec or OPT_FLAG says so (bit 3).
+func (f PTR) HiSpec() (m Measure, ok bool) {
+	s := specScale(f.HLM_SCAL, f.OPT_FLAG.HighLimitInvalid(), f.RES_SCAL, f.OPT_FLAG.ResScaleInvalid())
+	return Measure{f.HI_SPEC, s, string(f.UNITS)}, !f.IsMissing("HI_SPEC")
+}
+
+// Results returns RTN_RSLT scaled with RES_SCAL. ok is false if the
+// results are flagged invalid or the test was not executed.
+func (f MPR) Results() (m []Measure, ok bool) {
+	s := scale(f.RES_SCAL, f.OPT_FLAG.ResScaleInvalid())
+	m = make([]Measure, len(f.RTN_RSLT))
+	for i, r := range f.RTN_RSLT {
+		m[i] = Measure{r, s, string(f.UNITS)}
+	}
+	return m, f.TEST_FLG.ResultValid() && !f.TEST_FLG.NotExecuted()
+}
+
+// LoLimit returns LO_LIMIT scaled with LLM_SCAL. ok is false if the MPR
+// has no low limit or OPT_FLAG says it has none or it is invalid.
+func (f MPR) LoLimit() (m Measure, ok bool) {
+	m = Measure{f.LO_LIMIT, scale(f.LLM_SCAL, f.OPT_FLAG.LowLimitInvalid()), string(f.UNITS)}
+	return m, !f.IsMissing("LO_LIMIT")
+}
+
+// HiLimit returns HI_LIMIT scaled with HLM_SCAL. ok is false if the MPR
+// has no high limit or OPT_FLAG says it has none or it is invalid.
+func (f MPR) HiLimit() (m Measure, ok bool) {
+	m = Measure{f.HI_LIMIT, scale(f.HLM_SCAL, f.OPT_FLAG.HighLimitInvalid()), string(f.UNITS)}
+	return m, !f.IsMissing("HI_LIMIT")
+}
+
+// LoSpec returns LO_SPEC, scaled like the low limit. ok is false if the
+// MPR has no low spec or OPT_FLAG says so (bit 2).
+func (f MPR) LoSpec() (m Measure, ok bool) {
+	s := specScale(f.LLM_SCAL, f.OPT_FLAG.LowLimitInvalid(), f.RES_SCAL, f.OPT_FLAG.ResScaleInvalid())
+	return Measure{f.LO_SPEC, s, string(f.UNITS)}, !f.IsMissing("LO_SPEC")
+}
+
+// HiSpec returns HI_SPEC, scaled like the high limit. ok is false if the
+// MPR has no high spec or OPT_FLAG says so (bit 3).
+func (f MPR) HiSpec() (m Measure, ok bool) {
+	s := specScale(f.HLM_SCAL, f.OPT_FLAG.HighLimitInvalid(), f.RES_SCAL, f.OPT_FLAG.ResScaleInvalid())
+	return Measure{f.HI_SPEC, s, string(f.UNITS)}, !f.IsMissing("HI_SPEC")
+}
@@ -0,0 +1,64 @@
+package stdf
+
+import "testing"
+
+func TestMeasure(t *testing.T) {
+	p := PTR{RESULT: 0.0012, RES_SCAL: 3, LLM_SCAL: 6, HLM_SCAL: 6, LO_LIMIT: -5e-6, HI_LIMIT: 5e-6,
+		OPT_FLAG: 0x20, UNITS: CN("A")}
+	res, _ := p.Result()
+	lo, _ := p.LoLimit()
+	hi, _ := p.HiLimit()
+	for _, c := range []struct {
+		m    Measure
+		want string
+		si   float64
+	}{
+		{res, "1.2 mA", 0.0012},
+		{lo, "-5 uA", -5e-6},
+		// HI_LIMIT is invalid, so HLM_SCAL is not applied
+		{hi, "5e-06 A", 5e-6},
+		{Measure{Value: 2500, Scale: -3, Units: "Hz"}, "2.5 KHz", 2500},
+		{Measure{Value: 0.5, Scale: 2}, "50 %", 0.5},
+		{Measure{Value: 1.5, Scale: 1, Units: "V"}, "1.5 V", 1.5},
+	} {
+		if got := c.m.String(); got != c.want {
+			t.Errorf("%+v: got %q, want %q", c.m, got, c.want)
+		}
+		if got := c.m.SI(); got != c.si {
+			t.Errorf("%+v: SI %v, want %v", c.m, got, c.si)
+		}
+	}
+
+	m := MPR{RTN_RSLT: KXR4{1e-3, 2e-3}, RES_SCAL: 3, UNITS: CN("V")}
+	if r, ok := m.Results(); !ok || len(r) != 2 || r[1].String() != "2 mV" {
+		t.Errorf("got %v, %v", r, ok)
+	}
+}
+
+func TestMeasureValid(t *testing.T) {
+	// no low spec, high limit invalid, no low limit
+	p := PTR{TEST_FLG: 0x02, OPT_FLAG: 0x64, LO_SPEC: 1, HI_SPEC: 2, RES_SCAL: 3, HLM_SCAL: 6}
+	for _, c := range []struct {
+		name string
+		f    func() (Measure, bool)
+		want bool
+	}{
+		{"Result", p.Result, false},
+		{"LoLimit", p.LoLimit, false},
+		{"HiLimit", p.HiLimit, false},
+		{"LoSpec", p.LoSpec, false},
+		{"HiSpec", p.HiSpec, true},
+	} {
+		if _, ok := c.f(); ok != c.want {
+			t.Errorf("%s ok = %v, want %v", c.name, ok, c.want)
+		}
+	}
+	// with HLM_SCAL invalid the high spec is scaled like the result
+	if m, _ := p.HiSpec(); m.Scale != 3 {
+		t.Errorf("HiSpec scale %d, want 3", m.Scale)
+	}
+	m := MPR{TEST_FLG: 0x10, RTN_RSLT: KXR4{1}}
+	if _, ok := m.Results(); ok {
+		t.Error("results of a test not executed are valid")
+	}
+}
@@ -0,0 +1,418 @@
+package wafermap
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+// Options control how a map is rendered. The zero value draws a wafer 800
+// pixels across, its dies coloured by hard bin with DefaultPalette, and a
+// legend.
+type Options struct {
+	// Size is the wafer diameter in pixels; 0 means 800
+	Size int
+	// Palette colours bins; bins it leaves out get DefaultPalette colours
+	Palette map[stdf.U2]color.Color
+	// SoftBin colours dies by soft bin instead of hard bin
+	SoftBin bool
+	// NoLegend leaves out the legend
+	NoLegend bool
+	// Value, if set, draws a heat map instead of bins: dies are coloured by
+	// the value it returns, from blue for the lowest to red for the
+	// highest, and dies without a value are grey. See TestResult.
+	Value func(d *Die) (float64, bool)
+}
+
+// DefaultPalette colours bin b with DefaultPalette[b%16]: bin 1 is green,
+// the usual pass bin, the others are told apart by hue.
+var DefaultPalette = []color.RGBA{
+	{0x80, 0x80, 0x80, 0xff}, {0x2c, 0xa0, 0x2c, 0xff}, {0xd6, 0x27, 0x28, 0xff}, {0x1f, 0x77, 0xb4, 0xff},
+	{0xff, 0x7f, 0x0e, 0xff}, {0x94, 0x67, 0xbd, 0xff}, {0x8c, 0x56, 0x4b, 0xff}, {0xe3, 0x77, 0xc2, 0xff},
+	{0xbc, 0xbd, 0x22, 0xff}, {0x17, 0xbe, 0xcf, 0xff}, {0xff, 0xbb, 0x78, 0xff}, {0x98, 0xdf, 0x8a, 0xff},
+	{0xff, 0x98, 0x96, 0xff}, {0xae, 0xc7, 0xe8, 0xff}, {0xc5, 0xb0, 0xd5, 0xff}, {0x00, 0x00, 0x00, 0xff},
+}
+
+// TestResult returns a heat map Value that picks the result of test num
+// from a die's part: the RESULT of its PTR or the first result of its MPR,
+// in SI base units. The part needs its tests, see FromWafer.
+func TestResult(num stdf.U4) func(d *Die) (float64, bool) {
+	return func(d *Die) (float64, bool) {
+		if d.Part == nil {
+			return 0, false
+		}
+		switch rec := d.Part.Test(num).(type) {
+		case *stdf.PTR:
+			m, ok := rec.Result()
+			return m.SI(), ok
+		case *stdf.MPR:
+			if m, ok := rec.Results(); len(m) > 0 {
+				return m[0].SI(), ok
+			}
+		}
+		return 0, false
+	}
+}
+
+var (
+	waferColor = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	noValue    = color.RGBA{0x90, 0x90, 0x90, 0xff}
+	textColor  = color.RGBA{0, 0, 0, 0xff}
+	// heat map colours from low to high
+	heatStops = []color.RGBA{
+		{0x30, 0x30, 0xd0, 0xff}, {0x20, 0xb0, 0xe0, 0xff}, {0x30, 0xc0, 0x30, 0xff},
+		{0xf0, 0xd0, 0x20, 0xff}, {0xe0, 0x30, 0x20, 0xff},
+	}
+)
+
+const (
+	margin     = 10
+	swatch     = 12
+	legendLine = 18
+	heatBar    = 120
+)
+
+// rect is a rectangle in pixels.
+type rect struct{ x0, y0, x1, y1 float64 }
+
+type legendEntry struct {
+	c     color.RGBA
+	label string
+}
+
+// drawing is a map laid out in pixels, for both PNG and SVG.
+type drawing struct {
+	width, height int
+	// wafer centre and radius, and the direction of the flat in radians
+	// counter-clockwise from the positive X axis
+	cx, cy, r float64
+	flat      float64
+	hasFlat   bool
+	dies      []rect
+	colors    []color.RGBA
+	// legend origin and entries; a heat map has a colour bar between the
+	// labels of its highest and lowest values instead
+	lx, ly float64
+	legend []legendEntry
+	heat   bool
+}
+
+// flatDepth is the distance of the flat from the wafer centre, in radii.
+const flatDepth = 0.96
+
+func (m *Map) layout(o *Options) *drawing {
+	if o == nil {
+		o = &Options{}
+	}
+	size := o.Size
+	if size <= 0 {
+		size = 800
+	}
+	dies := m.Dies()
+	w, h := m.DieSize()
+
+	// radius in WF_UNITS: the wafer's, but large enough for every die
+	radius := m.WaferSize() / 2
+	for _, d := range dies {
+		px, py := m.Position(d.X, d.Y)
+		if r := math.Hypot(math.Abs(px)+w/2, math.Abs(py)+h/2); r > radius {
+			radius = r
+		}
+	}
+	if radius == 0 {
+		radius = 1
+	}
+	s := float64(size) / (2 * radius)
+	dr := &drawing{cx: margin + float64(size)/2, cy: margin + float64(size)/2, r: float64(size) / 2}
+	if a, ok := m.FlatAngle(); ok {
+		dr.flat, dr.hasFlat = a*math.Pi/180, true
+	}
+
+	gap := 0.0
+	if math.Min(w, h)*s >= 4 {
+		gap = 0.5
+	}
+	var values []float64
+	if o.Value != nil {
+		values = make([]float64, len(dies))
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	counts := make(map[stdf.U2]int)
+	for i, d := range dies {
+		px, py := m.Position(d.X, d.Y)
+		x0 := dr.cx + (px-w/2)*s
+		y0 := dr.cy - (py+h/2)*s
+		dr.dies = append(dr.dies, rect{x0 + gap, y0 + gap, x0 + w*s - gap, y0 + h*s - gap})
+		if o.Value != nil {
+			v, ok := o.Value(d)
+			if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+				v = math.NaN()
+			} else {
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+			values[i] = v
+			continue
+		}
+		bin := d.HardBin
+		if o.SoftBin {
+			bin = d.SoftBin
+		}
+		counts[bin]++
+		dr.colors = append(dr.colors, binColor(o, bin))
+	}
+
+	dr.lx, dr.ly = float64(2*margin+size), margin
+	legendW, legendH := 0, 0
+	if o.Value != nil {
+		dr.heat = true
+		for _, v := range values {
+			c := noValue
+			if !math.IsNaN(v) {
+				c = heatColor(v, lo, hi)
+			}
+			dr.colors = append(dr.colors, c)
+		}
+		if !math.IsInf(lo, 0) {
+			dr.legend = []legendEntry{
+				{heatColor(hi, lo, hi), strconv.FormatFloat(hi, 'g', 4, 64)},
+				{heatColor(lo, lo, hi), strconv.FormatFloat(lo, 'g', 4, 64)},
+			}
+			legendH = heatBar + 2*legendLine
+		}
+	} else {
+		bins := make([]stdf.U2, 0, len(counts))
+		for b := range counts {
+			bins = append(bins, b)
+		}
+		sort.Slice(bins, func(i, j int) bool { return bins[i] < bins[j] })
+		for _, b := range bins {
+			label := fmt.Sprintf("%d: %d (%.1f%%)", b, counts[b], 100*float64(counts[b])/float64(len(dies)))
+			dr.legend = append(dr.legend, legendEntry{binColor(o, b), label})
+		}
+		legendH = len(bins) * legendLine
+	}
+	if o.NoLegend {
+		dr.legend = nil
+		legendH = 0
+	}
+	for _, e := range dr.legend {
+		if n := swatch + 6 + textWidth(e.label); n > legendW {
+			legendW = n
+		}
+	}
+	dr.width = 2*margin + size
+	if legendW > 0 {
+		dr.width += legendW + margin
+	}
+	dr.height = 2*margin + size
+	if n := 2*margin + legendH; n > dr.height {
+		dr.height = n
+	}
+	return dr
+}
+
+func binColor(o *Options, bin stdf.U2) color.RGBA {
+	if c, ok := o.Palette[bin]; ok {
+		return color.RGBAModel.Convert(c).(color.RGBA)
+	}
+	return DefaultPalette[int(bin)%len(DefaultPalette)]
+}
+
+// heatColor returns the colour of v on the scale from lo to hi.
+func heatColor(v, lo, hi float64) color.RGBA {
+	f := 0.5
+	if hi > lo {
+		f = (v - lo) / (hi - lo)
+	}
+	f *= float64(len(heatStops) - 1)
+	i := int(f)
+	if i >= len(heatStops)-1 {
+		return heatStops[len(heatStops)-1]
+	}
+	a, b, t := heatStops[i], heatStops[i+1], f-float64(i)
+	mix := func(x, y uint8) uint8 { return uint8(float64(x) + t*(float64(y)-float64(x)) + 0.5) }
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 0xff}
+}
+
+// onWafer reports whether the pixel centre x, y lies on the wafer.
+func (dr *drawing) onWafer(x, y float64) bool {
+	dx, dy := x-dr.cx, y-dr.cy
+	if dx*dx+dy*dy > dr.r*dr.r {
+		return false
+	}
+	// image Y runs down
+	return !dr.hasFlat || dx*math.Cos(dr.flat)-dy*math.Sin(dr.flat) <= flatDepth*dr.r
+}
+
+// Image renders the map as an image.
+func (m *Map) Image(o *Options) *image.RGBA {
+	dr := m.layout(o)
+	img := image.NewRGBA(image.Rect(0, 0, dr.width, dr.height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y := 0; y < dr.height; y++ {
+		for x := 0; x < dr.width; x++ {
+			if dr.onWafer(float64(x)+0.5, float64(y)+0.5) {
+				img.SetRGBA(x, y, waferColor)
+			}
+		}
+	}
+	for i, r := range dr.dies {
+		fillRect(img, r, dr.colors[i])
+	}
+
+	x, y := dr.lx, dr.ly
+	if dr.heat && len(dr.legend) == 2 {
+		drawText(img, int(x), int(y), dr.legend[0].label)
+		y += legendLine
+		for j := 0; j < heatBar; j++ {
+			c := heatColor(float64(heatBar-1-j), 0, heatBar-1)
+			fillRect(img, rect{x, y + float64(j), x + swatch, y + float64(j+1)}, c)
+		}
+		drawText(img, int(x), int(y)+heatBar+4, dr.legend[1].label)
+		return img
+	}
+	for _, e := range dr.legend {
+		fillRect(img, rect{x, y, x + swatch, y + swatch}, e.c)
+		drawText(img, int(x)+swatch+6, int(y)+1, e.label)
+		y += legendLine
+	}
+	return img
+}
+
+// WritePNG writes the map to w as a PNG image.
+func (m *Map) WritePNG(w io.Writer, o *Options) error {
+	return png.Encode(w, m.Image(o))
+}
+
+func fillRect(img *image.RGBA, r rect, c color.RGBA) {
+	b := image.Rect(int(math.Round(r.x0)), int(math.Round(r.y0)), int(math.Round(r.x1)), int(math.Round(r.y1)))
+	if b.Empty() {
+		// dies smaller than a pixel still get one
+		b.Max = b.Min.Add(image.Pt(1, 1))
+	}
+	b = b.Intersect(img.Rect)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// WriteSVG writes the map to w as an SVG image.
+func (m *Map) WriteSVG(w io.Writer, o *Options) error {
+	dr := m.layout(o)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		dr.width, dr.height, dr.width, dr.height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", dr.width, dr.height)
+	if dr.hasFlat {
+		// the outline runs the long way round from one end of the flat to
+		// the other; SVG Y runs down, so counter-clockwise is sweep 0
+		a := math.Acos(flatDepth)
+		x0, y0 := dr.cx+dr.r*math.Cos(dr.flat+a), dr.cy-dr.r*math.Sin(dr.flat+a)
+		x1, y1 := dr.cx+dr.r*math.Cos(dr.flat-a), dr.cy-dr.r*math.Sin(dr.flat-a)
+		fmt.Fprintf(bw, `<path d="M%s %s A%s %s 0 1 0 %s %sZ" fill="%s"/>`+"\n",
+			num(x0), num(y0), num(dr.r), num(dr.r), num(x1), num(y1), hexColor(waferColor))
+	} else {
+		fmt.Fprintf(bw, `<circle cx="%s" cy="%s" r="%s" fill="%s"/>`+"\n",
+			num(dr.cx), num(dr.cy), num(dr.r), hexColor(waferColor))
+	}
+	for i, r := range dr.dies {
+		fmt.Fprintf(bw, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n",
+			num(r.x0), num(r.y0), num(r.x1-r.x0), num(r.y1-r.y0), hexColor(dr.colors[i]))
+	}
+
+	x, y := dr.lx, dr.ly
+	text := func(x, y float64, s string) {
+		fmt.Fprintf(bw, `<text x="%s" y="%s" font-family="sans-serif" font-size="12" fill="%s">%s</text>`+"\n",
+			num(x), num(y+10), hexColor(textColor), s)
+	}
+	if dr.heat && len(dr.legend) == 2 {
+		bw.WriteString(`<defs><linearGradient id="heat" x1="0" y1="1" x2="0" y2="0">`)
+		for i, c := range heatStops {
+			fmt.Fprintf(bw, `<stop offset="%s" stop-color="%s"/>`, num(float64(i)/float64(len(heatStops)-1)), hexColor(c))
+		}
+		bw.WriteString("</linearGradient></defs>\n")
+		text(x, y, dr.legend[0].label)
+		fmt.Fprintf(bw, `<rect x="%s" y="%s" width="%d" height="%d" fill="url(#heat)"/>`+"\n",
+			num(x), num(y+legendLine), swatch, heatBar)
+		text(x, y+legendLine+heatBar+4, dr.legend[1].label)
+	} else {
+		for _, e := range dr.legend {
+			fmt.Fprintf(bw, `<rect x="%s" y="%s" width="%d" height="%d" fill="%s"/>`+"\n",
+				num(x), num(y), swatch, swatch, hexColor(e.c))
+			text(x+swatch+6, y, e.label)
+			y += legendLine
+		}
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+// num formats a coordinate for SVG.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// glyphs is a 3×5 pixel font for legend labels, drawn at twice the size;
+// each row is three bits, the high bit on the left.
+var glyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 2, 2},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'.': {0, 0, 0, 0, 2},
+	'-': {0, 0, 7, 0, 0},
+	'+': {0, 2, 7, 2, 0},
+	'e': {0, 2, 7, 4, 3},
+	'%': {5, 1, 2, 4, 5},
+	':': {0, 2, 0, 2, 0},
+	'(': {1, 2, 2, 2, 1},
+	')': {4, 2, 2, 2, 4},
+}
+
+const (
+	glyphScale   = 2
+	glyphAdvance = 4 * glyphScale
+)
+
+func textWidth(s string) int {
+	return len([]rune(s)) * glyphAdvance
+}
+
+// drawText draws s with its top left corner at x, y. Characters the font
+// does not have are left blank.
+func drawText(img *image.RGBA, x, y int, s string) {
+	for _, r := range s {
+		g := glyphs[r]
+		for row, bits := range g {
+			for col := 0; col < 3; col++ {
+				if bits&(4>>col) != 0 {
+					fx, fy := float64(x+col*glyphScale), float64(y+row*glyphScale)
+					fillRect(img, rect{fx, fy, fx + glyphScale, fy + glyphScale}, textColor)
+				}
+			}
+		}
+		x += glyphAdvance
+	}
+}
@@ -0,0 +1,111 @@
+package wafermap
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+func renderMap(t *testing.T) *Map {
+	t.Helper()
+	wcr := stdf.WCR{WAFR_SIZ: 20, DIE_HT: 4, DIE_WID: 4, WF_UNITS: 3, WF_FLAT: 'D', CENTER_X: 1, CENTER_Y: 1,
+		POS_X: 'R', POS_Y: 'D'}
+	lot, err := stdf.ReadLot(bytes.NewReader(waferStream(t, wcr)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return FromWafer(lot.Wafers[0], lot.WCR)
+}
+
+func TestImage(t *testing.T) {
+	m := renderMap(t)
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	var buf bytes.Buffer
+	if err := m.WritePNG(&buf, &Options{Size: 200, Palette: map[stdf.U2]color.Color{7: red}}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := img.Bounds()
+	if b.Dx() <= 220 || b.Dy() != 220 {
+		t.Fatalf("got size %v, want a 220 pixel high image with a legend", b)
+	}
+	at := func(x, y int) color.RGBA { return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA) }
+	// 10 pixels a unit around the centre die 1, 1, which is not tested,
+	// with Y growing down
+	for _, c := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{70, 70, DefaultPalette[1]},
+		{110, 70, DefaultPalette[1]},
+		{150, 110, DefaultPalette[1]},
+		{70, 150, red},
+		{110, 110, waferColor},
+		{110, 15, waferColor},
+		// below the flat
+		{110, 207, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+	} {
+		if got := at(c.x, c.y); got != c.want {
+			t.Errorf("pixel %d, %d: got %v, want %v", c.x, c.y, got, c.want)
+		}
+	}
+	// the legend swatches of bins 1 and 7
+	if got := at(226, 16); got != DefaultPalette[1] {
+		t.Errorf("legend bin 1: got %v", got)
+	}
+	if got := at(226, 34); got != red {
+		t.Errorf("legend bin 7: got %v", got)
+	}
+
+	plain := m.Image(&Options{Size: 200, NoLegend: true})
+	if plain.Bounds().Dx() != 220 {
+		t.Errorf("got width %d without legend", plain.Bounds().Dx())
+	}
+
+	heat := m.Image(&Options{Size: 200, Value: TestResult(1), NoLegend: true})
+	// die 0 has the lowest result, 2 the highest
+	if got := heat.RGBAAt(70, 150); got != heatStops[0] {
+		t.Errorf("lowest value: got %v", got)
+	}
+	if got := heat.RGBAAt(150, 110); got != heatStops[len(heatStops)-1] {
+		t.Errorf("highest value: got %v", got)
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	m := renderMap(t)
+	var buf bytes.Buffer
+	if err := m.WriteSVG(&buf, &Options{Size: 200}); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="`,
+		`<path d="M`,
+		`<rect x="50.5" y="130.5" width="39" height="39" fill="#e377c2"/>`,
+		`>1: 3 (75.0%)</text>`,
+		`>7: 1 (25.0%)</text>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("no %s in\n%s", want, svg)
+		}
+	}
+	if n := strings.Count(svg, "<rect"); n != 1+4+2 {
+		t.Errorf("got %d rects", n)
+	}
+
+	buf.Reset()
+	if err := m.WriteSVG(&buf, &Options{Value: TestResult(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if svg := buf.String(); !strings.Contains(svg, `fill="url(#heat)"`) || !strings.Contains(svg, ">2</text>") {
+		t.Errorf("no heat map legend in\n%s", svg)
+	}
+}
@@ -0,0 +1,259 @@
+// Package wafermap builds wafer maps from STDF probe data: the bins of the
+// dies of a wafer by their X_COORD and Y_COORD, laid out with the wafer
+// configuration of the WCR.
+//
+// Maps render to PNG and SVG, coloured by bin or as a heat map of a test's
+// results, see Options.
+package wafermap
+
+import (
+	"image"
+	"io"
+	"math"
+	"sort"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+// Die is a tested die of a wafer map.
+type Die struct {
+	X, Y    int
+	HardBin stdf.U2
+	// SoftBin is 65535 if the PRR has none
+	SoftBin stdf.U2
+	Passed  bool
+	// Tested counts the PRRs of the die; more than one means it was
+	// retested and the last test is the one that counts
+	Tested int
+	// Part is the part of the last test; its Tests are empty unless the
+	// map was made with FromWafer
+	Part *stdf.Part
+}
+
+// Map is the wafer map of one wafer.
+type Map struct {
+	WIR *stdf.WIR
+	// WRR is nil until the wafer is finished
+	WRR *stdf.WRR
+	// WCR is nil if the file has no wafer configuration; the map then
+	// has positive X to the right and positive Y up, and dies one unit in
+	// size
+	WCR  *stdf.WCR
+	dies map[image.Point]*Die
+}
+
+// New returns an empty map of the wafer wir, laid out by wcr, which may be
+// nil.
+func New(wir *stdf.WIR, wcr *stdf.WCR) *Map {
+	return &Map{WIR: wir, WCR: wcr, dies: make(map[image.Point]*Die)}
+}
+
+// FromWafer returns the map of a wafer of a stdf.Lot, laid out by wcr,
+// usually the lot's WCR.
+func FromWafer(w *stdf.Wafer, wcr *stdf.WCR) *Map {
+	m := New(w.WIR, wcr)
+	m.WRR = w.WRR
+	for _, p := range w.Parts {
+		m.AddPart(p)
+	}
+	return m
+}
+
+// Read reads the STDF stream r to its end and returns the map of every
+// wafer in it, in the order of their WIRs. The WCR, wherever it is in the
+// stream, lays out all of them. Only the PIR and PRR of each part are
+// kept; use FromWafer with stdf.ReadLot to keep test results.
+func Read(r io.Reader) ([]*Map, error) {
+	rd := stdf.NewReader(r)
+	var (
+		maps []*Map
+		wcr  *stdf.WCR
+		pirs = make(map[[2]stdf.U1]*stdf.PIR)
+		open = make(map[stdf.U1]*Map)
+	)
+	for {
+		rec, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch rec := rec.(type) {
+		case *stdf.WCR:
+			wcr = rec
+		case *stdf.WIR:
+			m := New(rec, nil)
+			open[rec.HEAD_NUM] = m
+			maps = append(maps, m)
+		case *stdf.WRR:
+			if m := open[rec.HEAD_NUM]; m != nil {
+				m.WRR = rec
+				delete(open, rec.HEAD_NUM)
+			}
+		case *stdf.PIR:
+			pirs[[2]stdf.U1{rec.HEAD_NUM, rec.SITE_NUM}] = rec
+		case *stdf.PRR:
+			site := [2]stdf.U1{rec.HEAD_NUM, rec.SITE_NUM}
+			if m := open[rec.HEAD_NUM]; m != nil {
+				m.AddPart(&stdf.Part{PIR: pirs[site], PRR: rec})
+			}
+			delete(pirs, site)
+		}
+	}
+	for _, m := range maps {
+		m.WCR = wcr
+	}
+	return maps, nil
+}
+
+// AddPart puts p on the map at the coordinates of its PRR. A part without
+// a PRR or without coordinates is not added and AddPart returns false. A
+// die tested before is replaced by the retest.
+func (m *Map) AddPart(p *stdf.Part) bool {
+	prr := p.PRR
+	if prr == nil || prr.IsMissing("X_COORD") || prr.IsMissing("Y_COORD") {
+		return false
+	}
+	pt := image.Pt(int(prr.X_COORD), int(prr.Y_COORD))
+	d := m.dies[pt]
+	if d == nil {
+		d = &Die{X: pt.X, Y: pt.Y}
+		m.dies[pt] = d
+	}
+	d.HardBin, d.SoftBin = prr.HARD_BIN, prr.SOFT_BIN
+	d.Passed = prr.PART_FLG.Passed()
+	d.Tested++
+	d.Part = p
+	return true
+}
+
+// Die returns the die at x, y, or nil if there is none.
+func (m *Map) Die(x, y int) *Die {
+	return m.dies[image.Pt(x, y)]
+}
+
+// Dies returns the dies of the map by Y, then X coordinate.
+func (m *Map) Dies() []*Die {
+	dies := make([]*Die, 0, len(m.dies))
+	for _, d := range m.dies {
+		dies = append(dies, d)
+	}
+	sort.Slice(dies, func(i, j int) bool {
+		if dies[i].Y != dies[j].Y {
+			return dies[i].Y < dies[j].Y
+		}
+		return dies[i].X < dies[j].X
+	})
+	return dies
+}
+
+// Bounds returns the smallest rectangle of coordinates holding every die;
+// Max is exclusive.
+func (m *Map) Bounds() image.Rectangle {
+	var r image.Rectangle
+	first := true
+	for pt := range m.dies {
+		die := image.Rectangle{pt, pt.Add(image.Pt(1, 1))}
+		if first {
+			r, first = die, false
+		} else {
+			r = r.Union(die)
+		}
+	}
+	return r
+}
+
+// Yield returns the fraction of the dies that passed their last test, or
+// NaN for an empty map.
+func (m *Map) Yield() float64 {
+	if len(m.dies) == 0 {
+		return math.NaN()
+	}
+	good := 0
+	for _, d := range m.dies {
+		if d.Passed {
+			good++
+		}
+	}
+	return float64(good) / float64(len(m.dies))
+}
+
+// WaferSize returns the wafer diameter in the WCR's WF_UNITS, or 0 if it
+// is not known.
+func (m *Map) WaferSize() float64 {
+	if m.WCR == nil {
+		return 0
+	}
+	return float64(m.WCR.WAFR_SIZ)
+}
+
+// DieSize returns the width and height of a die in the WCR's WF_UNITS, or
+// 1 and 1 if they are not known.
+func (m *Map) DieSize() (w, h float64) {
+	w, h = 1, 1
+	if m.WCR != nil && m.WCR.DIE_WID > 0 && m.WCR.DIE_HT > 0 {
+		w, h = float64(m.WCR.DIE_WID), float64(m.WCR.DIE_HT)
+	}
+	return w, h
+}
+
+// Center returns the coordinates of the die at the centre of the wafer,
+// from the WCR, or the middle of the map's bounds if it does not say.
+func (m *Map) Center() (x, y float64) {
+	b := m.Bounds()
+	x = float64(b.Min.X+b.Max.X-1) / 2
+	y = float64(b.Min.Y+b.Max.Y-1) / 2
+	if m.WCR != nil && !m.WCR.IsMissing("CENTER_X") {
+		x = float64(m.WCR.CENTER_X)
+	}
+	if m.WCR != nil && !m.WCR.IsMissing("CENTER_Y") {
+		y = float64(m.WCR.CENTER_Y)
+	}
+	return x, y
+}
+
+// Direction returns the directions the X and Y coordinates grow in on the
+// wafer: +1, +1 for positive X to the right and positive Y up, which is
+// also what a WCR without POS_X and POS_Y gets, and -1 for left or down.
+func (m *Map) Direction() (dx, dy int) {
+	dx, dy = 1, 1
+	if m.WCR != nil && m.WCR.POS_X == 'L' {
+		dx = -1
+	}
+	if m.WCR != nil && m.WCR.POS_Y == 'D' {
+		dy = -1
+	}
+	return dx, dy
+}
+
+// Position returns where the centre of die x, y lies on the wafer: its
+// offset from the wafer centre in WF_UNITS, with positive X to the right
+// and positive Y up as the wafer lies with its flat where WCR.WF_FLAT
+// says.
+func (m *Map) Position(x, y int) (px, py float64) {
+	cx, cy := m.Center()
+	w, h := m.DieSize()
+	dx, dy := m.Direction()
+	return (float64(x) - cx) * w * float64(dx), (float64(y) - cy) * h * float64(dy)
+}
+
+// FlatAngle returns the direction of the flat or notch from the wafer
+// centre in degrees, counter-clockwise from the positive X axis: 270 for
+// a flat down. ok is false if the WCR does not say.
+func (m *Map) FlatAngle() (deg float64, ok bool) {
+	if m.WCR == nil {
+		return 0, false
+	}
+	switch m.WCR.WF_FLAT {
+	case 'R':
+		return 0, true
+	case 'U':
+		return 90, true
+	case 'L':
+		return 180, true
+	case 'D':
+		return 270, true
+	}
+	return 0, false
+}
@@ -0,0 +1,132 @@
+package wafermap
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	stdf "unicompound.com/stdf/v1"
+)
+
+func waferStream(t *testing.T, wcr stdf.WCR) []byte {
+	t.Helper()
+	var bin bytes.Buffer
+	w := stdf.NewWriter(&bin)
+	recs := []stdf.StdfRecordType{
+		stdf.FAR{Cpu_Type: 2, Stdf_Ver: 4},
+		stdf.MIR{MODE_COD: 'P', RTST_COD: ' ', PROT_COD: ' ', BURN_TIM: 65535, CMOD_COD: ' '},
+		stdf.WIR{HEAD_NUM: 1, SITE_GRP: 255, WAFER_ID: stdf.CN("W1")},
+	}
+	die := func(site stdf.U1, x, y stdf.I2, flg stdf.PartFlag, bin stdf.U2) []stdf.StdfRecordType {
+		return []stdf.StdfRecordType{
+			stdf.PIR{HEAD_NUM: 1, SITE_NUM: site},
+			stdf.PTR{TEST_NUM: 1, HEAD_NUM: 1, SITE_NUM: site, RESULT: stdf.R4(x)},
+			stdf.PRR{HEAD_NUM: 1, SITE_NUM: site, PART_FLG: flg, HARD_BIN: bin, SOFT_BIN: 65535,
+				X_COORD: x, Y_COORD: y},
+		}
+	}
+	recs = append(recs, die(1, 0, 0, 0, 1)...)
+	recs = append(recs, die(2, 1, 0, 0x08, 5)...)
+	recs = append(recs, die(1, 2, 1, 0, 1)...)
+	recs = append(recs, die(2, -32768, -32768, 0, 1)...)
+	// a retest of the failed die passes
+	recs = append(recs, die(1, 1, 0, 0x02, 1)...)
+	recs = append(recs, die(2, 0, 2, 0x08, 7)...)
+	recs = append(recs,
+		stdf.WRR{HEAD_NUM: 1, SITE_GRP: 255, PART_CNT: 6, WAFER_ID: stdf.CN("W1")},
+		wcr,
+		stdf.MRR{DISP_COD: ' '},
+	)
+	for _, rec := range recs {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bin.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	wcr := stdf.WCR{WAFR_SIZ: 200, DIE_HT: 5, DIE_WID: 4, WF_UNITS: 3, WF_FLAT: 'D', CENTER_X: 1,
+		CENTER_Y: 1, POS_X: 'L', POS_Y: 'U'}
+	maps, err := Read(bytes.NewReader(waferStream(t, wcr)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(maps) != 1 {
+		t.Fatalf("got %d maps", len(maps))
+	}
+	m := maps[0]
+	if string(m.WIR.WAFER_ID) != "W1" || m.WRR == nil || m.WCR == nil {
+		t.Fatalf("got map %+v", m)
+	}
+	dies := m.Dies()
+	if len(dies) != 4 || dies[0].X != 0 || dies[1].X != 1 || dies[2].Y != 1 || dies[3].Y != 2 {
+		t.Fatalf("got dies %+v", dies)
+	}
+	d := m.Die(1, 0)
+	if d.Tested != 2 || !d.Passed || d.HardBin != 1 || d.SoftBin != 65535 || d.Part.PIR == nil || d.Part.PRR == nil {
+		t.Errorf("retested die: got %+v", d)
+	}
+	if m.Die(5, 5) != nil {
+		t.Errorf("got a die at 5, 5")
+	}
+	if b := m.Bounds(); b != image.Rect(0, 0, 3, 3) {
+		t.Errorf("got bounds %v", b)
+	}
+	if y := m.Yield(); y != 0.75 {
+		t.Errorf("got yield %v", y)
+	}
+
+	if m.WaferSize() != 200 {
+		t.Errorf("got wafer size %v", m.WaferSize())
+	}
+	if w, h := m.DieSize(); w != 4 || h != 5 {
+		t.Errorf("got die size %v x %v", w, h)
+	}
+	// positive X runs left, so die 0 is right of the centre die 1
+	if x, y := m.Position(0, 2); x != 4 || y != 5 {
+		t.Errorf("got position %v, %v", x, y)
+	}
+	if a, ok := m.FlatAngle(); !ok || a != 270 {
+		t.Errorf("got flat angle %v, %v", a, ok)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	// a WCR with everything missing
+	wcr := stdf.WCR{WF_FLAT: ' ', CENTER_X: -32768, CENTER_Y: -32768, POS_X: ' ', POS_Y: ' '}
+	maps, err := Read(bytes.NewReader(waferStream(t, wcr)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := maps[0]
+	if x, y := m.Center(); x != 1 || y != 1 {
+		t.Errorf("got centre %v, %v", x, y)
+	}
+	if dx, dy := m.Direction(); dx != 1 || dy != 1 {
+		t.Errorf("got direction %v, %v", dx, dy)
+	}
+	if x, y := m.Position(0, 2); x != -1 || y != 1 {
+		t.Errorf("got position %v, %v", x, y)
+	}
+	if _, ok := m.FlatAngle(); ok {
+		t.Errorf("got a flat angle")
+	}
+}
+
+func TestFromWafer(t *testing.T) {
+	lot, err := stdf.ReadLot(bytes.NewReader(waferStream(t, stdf.WCR{})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := FromWafer(lot.Wafers[0], lot.WCR)
+	if len(m.Dies()) != 4 || m.WCR == nil {
+		t.Fatalf("got %d dies", len(m.Dies()))
+	}
+	if ptr, ok := m.Die(2, 1).Part.Test(1).(*stdf.PTR); !ok || ptr.RESULT != 2 {
+		t.Errorf("got test %v", m.Die(2, 1).Part.Test(1))
+	}
+}
@@ -0,0 +1,142 @@
+package stdf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrRecordOrder is wrapped by the errors a Writer reports for records
+// written out of the order the STDF specification requires.
+var ErrRecordOrder = errors.New("stdf: record out of order")
+
+// Writer writes STDF records to an io.Writer.
+//
+// The first record must be the FAR. REC_TYP and REC_SUB are stamped from the
+// concrete record type and REC_LEN from the encoded payload, so callers do
+// not need to fill BasicRecordType. Records are encoded in the byte order
+// of the FAR's CPU_TYPE (see FAR.ByteOrder): write FAR{Cpu_Type: 1, ...}
+// for a big-endian file and FAR{Cpu_Type: 2, ...} for a little-endian one.
+//
+// Violations of the initial sequence (FAR, ATRs, MIR, RDR, SDRs) and of the
+// MRR being last are remembered and reported by Flush and Close, unless
+// that is turned off with CheckOrder.
+type Writer struct {
+	w     *bufio.Writer
+	order binary.ByteOrder
+	n     int
+	last  U1
+	mir   bool
+	mrr   bool
+	bad   error
+	// records are written in whatever order they come
+	lax bool
+}
+
+// NewWriter returns a Writer that writes STDF records to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w), order: binary.LittleEndian}
+}
+
+// CheckOrder turns the checks of record order and of a complete stream on,
+// as they are for a new Writer, or off. With them off the Writer writes
+// what it is given, as a copy of a file that breaks the order should be;
+// a stream that does not start with a FAR is written little-endian, as a
+// Reader reads it.
+func (w *Writer) CheckOrder(on bool) { w.lax = !on }
+
+// Write encodes rec and writes it to the stream.
+// A first record that is not a FAR is refused and nothing is written.
+func (w *Writer) Write(rec StdfRecordType) error {
+	typ, sub, ok := RecordCode(rec)
+	if !ok {
+		return fmt.Errorf("stdf: %T is not an STDF record", rec)
+	}
+	if w.n == 0 && !w.lax && (typ != 0 || sub != 10) {
+		return fmt.Errorf("%w: first record must be FAR, got %d/%d", ErrRecordOrder, typ, sub)
+	}
+	switch far := rec.(type) {
+	case FAR:
+		w.order = far.ByteOrder()
+	case *FAR:
+		w.order = far.ByteOrder()
+	}
+	b, err := encodeRecord(typ, sub, rec, w.order)
+	if err != nil {
+		return err
+	}
+	if _, err := w.w.Write(b); err != nil {
+		return err
+	}
+	w.check(typ, sub)
+	w.n++
+	return nil
+}
+
+// check records the first ordering violation caused by writing typ/sub.
+func (w *Writer) check(typ, sub U1) {
+	if w.bad != nil || w.lax {
+		return
+	}
+	var msg string
+	switch {
+	case w.mrr:
+		msg = "record after MRR"
+	case typ == 0 && sub == 10 && w.n > 0:
+		msg = "FAR is not the first record"
+	case typ == 0 && sub == 20 && w.mir:
+		msg = "ATR after MIR"
+	case typ == 1 && sub == 10 && w.mir:
+		msg = "more than one MIR"
+	case typ == 1 && sub == 70 && w.last != 10:
+		msg = "RDR not immediately after MIR"
+	case typ == 1 && sub == 80 && w.last != 10 && w.last != 70 && w.last != 80:
+		msg = "SDR outside the initial sequence"
+	case typ != 0 && !w.mir && !(typ == 1 && sub == 10):
+		msg = "record before MIR"
+	}
+	if msg != "" {
+		w.bad = fmt.Errorf("%w: %s (record %d, type %d/%d)", ErrRecordOrder, msg, w.n, typ, sub)
+	}
+	switch {
+	case typ == 1 && sub == 10:
+		w.mir = true
+	case typ == 1 && sub == 20:
+		w.mrr = true
+	}
+	// the initial sequence is tracked by the sub-type of the last lot record
+	w.last = 0
+	if typ == 1 {
+		w.last = sub
+	}
+}
+
+// Flush writes any buffered data to the underlying io.Writer and reports
+// the first ordering violation seen so far.
+func (w *Writer) Flush() error {
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	return w.bad
+}
+
+// Close flushes the Writer and checks that the stream is complete: it must
+// hold a FAR, a MIR and end with an MRR. It does not close the underlying
+// io.Writer.
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	switch {
+	case w.lax:
+	case w.n == 0:
+		return fmt.Errorf("%w: no FAR written", ErrRecordOrder)
+	case !w.mir:
+		return fmt.Errorf("%w: no MIR written", ErrRecordOrder)
+	case !w.mrr:
+		return fmt.Errorf("%w: no MRR written", ErrRecordOrder)
+	}
+	return nil
+}
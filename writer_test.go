@@ -0,0 +1,83 @@
+package stdf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	recs := []StdfRecordType{
+		FAR{Cpu_Type: 2, Stdf_Ver: 4},
+		&MIR{SETUP_T: 1, LOT_ID: CN("LOT1")},
+		PIR{HEAD_NUM: 1, SITE_NUM: 2},
+		PRR{HEAD_NUM: 1, SITE_NUM: 2, HARD_BIN: 1, PART_ID: CN("1")},
+		MRR{FINISH_T: 2, DISP_COD: ' '},
+	}
+	for _, rec := range recs {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(&buf)
+	for _, want := range recs {
+		got, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		gt, gs, _ := RecordCode(got)
+		wt, ws, _ := RecordCode(want)
+		if gt != wt || gs != ws {
+			t.Errorf("got record %d/%d, want %d/%d", gt, gs, wt, ws)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+}
+
+func TestWriterOrder(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.Write(MIR{}); !errors.Is(err, ErrRecordOrder) {
+		t.Errorf("MIR first: got %v, want ErrRecordOrder", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("refused record was written")
+	}
+
+	for _, rec := range []StdfRecordType{FAR{}, MIR{}, MRR{}, PIR{}} {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); !errors.Is(err, ErrRecordOrder) {
+		t.Errorf("record after MRR: got %v, want ErrRecordOrder", err)
+	}
+
+	w = NewWriter(io.Discard)
+	w.Write(FAR{})
+	w.Write(MIR{})
+	if err := w.Close(); !errors.Is(err, ErrRecordOrder) {
+		t.Errorf("missing MRR: got %v, want ErrRecordOrder", err)
+	}
+
+	buf.Reset()
+	w = NewWriter(&buf)
+	w.CheckOrder(false)
+	for _, rec := range []StdfRecordType{MIR{}, FAR{}, PIR{}} {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil || buf.Len() == 0 {
+		t.Errorf("unchecked order: got %v, %d bytes", err, buf.Len())
+	}
+}